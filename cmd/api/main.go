@@ -4,23 +4,30 @@ import (
 	"context"
 	"io"
 	stdlog "log"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/ntentasd/nostradamus-api/internal/arroyo"
+	"github.com/ntentasd/nostradamus-api/internal/auth"
 	"github.com/ntentasd/nostradamus-api/internal/cache"
 	"github.com/ntentasd/nostradamus-api/internal/db"
 	"github.com/ntentasd/nostradamus-api/internal/emqx"
 	"github.com/ntentasd/nostradamus-api/internal/kafka"
+	"github.com/ntentasd/nostradamus-api/internal/notify"
 	routes "github.com/ntentasd/nostradamus-api/internal/routes"
+	"github.com/ntentasd/nostradamus-api/internal/secrets"
 	"github.com/ntentasd/nostradamus-api/internal/tracing"
 	"github.com/ntentasd/nostradamus-api/internal/worker"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
 )
 
 var (
@@ -60,41 +67,33 @@ func main() {
 		log.Fatal().Err(err).Msg("unable to connect to data keyspace")
 	}
 
+	keyring := loadKeyring()
+
 	dbLogger := log.Logger.With().Str("component", "db").Logger()
-	store := db.New(metaSess, dataSess, dbLogger)
+	store := db.New(metaSess, dataSess, keyring, dbLogger)
 	defer store.Close()
 
-	var valkeyAddrs []string
-	if nodes := os.Getenv("VALKEY_NODES"); nodes != "" {
-		valkeyAddrs = strings.Split(nodes, ",")
-	}
-
-	var memcachedAddr string
-	if node := os.Getenv("MEMCACHED_NODE"); node != "" {
-		memcachedAddr = node
-	}
-
-	if len(valkeyAddrs) == 0 && memcachedAddr == "" {
-		log.Fatal().Msg("VALKEY_NODES or MEMCACHED_NODE must be set")
-	}
-
-	if len(valkeyAddrs) > 0 && memcachedAddr != "" {
-		log.Fatal().Msg("only one of VALKEY_NODES or MEMCACHED_NODE may be set")
+	c, config := newCache()
+	if v := os.Getenv("AGGREGATE_STALE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatal().Str("value", v).Msg("invalid AGGREGATE_STALE_TTL")
+		}
+		config.WithAggregateStaleTTL(d)
+		log.Info().Dur("stale_ttl", d).Msg("serving stale aggregates while revalidating")
 	}
 
-	var (
-		c      cache.Cache
-		config *routes.Config
-	)
-	if len(valkeyAddrs) > 0 {
-		c = cache.NewValkey(valkeyAddrs)
-		config = routes.NewConfig("valkey")
-		log.Info().Msg("using Valkey cache driver")
-	} else {
-		c = cache.NewMemcached(memcachedAddr)
-		config = routes.NewConfig("memcached")
-		log.Info().Msg("using Memcached cache driver")
+	var admins []uuid.UUID
+	if ids := os.Getenv("ADMIN_USER_IDS"); ids != "" {
+		for _, s := range strings.Split(ids, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(s))
+			if err != nil {
+				log.Fatal().Err(err).Str("value", s).Msg("invalid ADMIN_USER_IDS entry")
+			}
+			admins = append(admins, id)
+		}
 	}
+	config.WithAdmins(admins)
 	defer c.Close()
 
 	arroyoLogger := log.Logger.With().Str("component", "arroyo_client").Logger()
@@ -123,8 +122,17 @@ func main() {
 
 	emqxClient := emqx.New()
 
+	authn := newAuthenticator()
+
 	appLogger := log.Logger.With().Str("component", "app").Logger()
-	app := routes.New(store, c, ac, emqxClient, appLogger, config)
+	namespace := os.Getenv("CACHE_NAMESPACE")
+	if namespace == "" {
+		namespace = cache.DefaultNamespace
+	}
+	keys := cache.NewKeys(namespace)
+	codec := newCodec()
+
+	app := routes.New(store, c, keys, codec, ac, emqxClient, authn, appLogger, config)
 
 	shutdown := tracing.InitTracer()
 	defer shutdown(context.Background())
@@ -134,20 +142,314 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	keysLogger := log.Logger.With().Str("component", "cache_keys").Logger()
+	go keys.Sync(ctx, c, time.Second*10, keysLogger)
+
 	watcherLogger := log.Logger.With().Str("component", "kafka_watcher").Logger()
 	watcher := kafka.NewWatcher(kafkaBrokers, ac, pCache, watcherLogger)
 	go watcher.Run(ctx)
 
+	// Replicas share a remote cache, so one consumer per group is enough. An in-process
+	// cache needs every replica to see every reading.
+	cacheGroup := os.Getenv("KAFKA_CACHE_GROUP")
+	if cacheGroup == "" {
+		cacheGroup = "nostradamus-api-cache"
+	}
+	if os.Getenv("CACHE_DRIVER") == "memory" {
+		hostname, _ := os.Hostname()
+		cacheGroup += "-" + hostname
+	}
+
+	publish := app.Hub.Publish
+
+	// Every replica tails every reading, so alert evaluation and notifications are opt-in
+	// with ALERT_EVALUATOR=true and must be enabled on exactly one replica. More would
+	// record every transition and send every notification once per replica.
+	if evaluate, err := strconv.ParseBool(os.Getenv("ALERT_EVALUATOR")); err == nil && evaluate {
+		dispatcherLogger := log.Logger.With().Str("component", "notification_dispatcher").Logger()
+		dispatcher := notify.NewDispatcher(store, emqxClient, dispatcherLogger)
+		dispatcher.Start(ctx)
+		defer dispatcher.Stop()
+
+		evaluatorLogger := log.Logger.With().Str("component", "alert_evaluator").Logger()
+		evaluator := worker.NewAlertEvaluator(store, time.Second*30, dispatcher.Notify, evaluatorLogger)
+		evaluator.Start(ctx)
+		defer evaluator.Stop()
+
+		publish = func(r types.Reading) {
+			app.Hub.Publish(r)
+			evaluator.Observe(r)
+		}
+	}
+
+	tailLogger := log.Logger.With().Str("component", "kafka_tail").Logger()
+	tail := kafka.NewTail(kafkaBrokers, publish, tailLogger)
+	go tail.Run(ctx)
+
+	updaterLogger := log.Logger.With().Str("component", "cache_updater").Logger()
+	updater := kafka.NewCacheUpdater(kafkaBrokers, cacheGroup, c, keys, codec, time.Second*2, updaterLogger)
+	go updater.Run(ctx)
+
 	supervisorLogger := log.Logger.With().Str("component", "supervisor").Logger()
 	sv := worker.NewSupervisor(ac, time.Second*5, supervisorLogger)
 	sv.Start(context.Background())
 	defer sv.Stop()
 
+	// The reconciler deletes and restores EMQX users in two passes, replicas running it
+	// at once would race each other. It is opt-in with RECONCILER=true and must be enabled
+	// on exactly one replica.
+	if reconcile, err := strconv.ParseBool(os.Getenv("RECONCILER")); err == nil && reconcile {
+		reconcilerLogger := log.Logger.With().Str("component", "reconciler").Logger()
+		rc := worker.NewReconciler(store, emqxClient, time.Minute*5, reconcilerLogger)
+		rc.Start(context.Background())
+		defer rc.Stop()
+	}
+
 	log.Info().Msg("Warming up connections")
 	app.WarmUp()
 
+	// Warm-up reads every active sensor from Scylla into the shared cache, so it is opt-in
+	// with CACHE_WARMUP=true and should run on a single replica
+	if warmup, err := strconv.ParseBool(os.Getenv("CACHE_WARMUP")); err == nil && warmup {
+		warmupInterval := 15 * time.Minute
+		if v := os.Getenv("CACHE_WARMUP_INTERVAL"); v != "" {
+			warmupInterval, err = time.ParseDuration(v)
+			if err != nil || warmupInterval < 0 {
+				log.Fatal().Str("value", v).Msg("invalid CACHE_WARMUP_INTERVAL")
+			}
+		}
+
+		warmupConcurrency := 8
+		if v := os.Getenv("CACHE_WARMUP_CONCURRENCY"); v != "" {
+			warmupConcurrency, err = strconv.Atoi(v)
+			if err != nil || warmupConcurrency <= 0 {
+				log.Fatal().Str("value", v).Msg("invalid CACHE_WARMUP_CONCURRENCY")
+			}
+		}
+
+		awaitWarmup, _ := strconv.ParseBool(os.Getenv("READY_AWAIT_WARMUP"))
+		config.WithReadyAwaitsWarmup(awaitWarmup)
+
+		warmerLogger := log.Logger.With().Str("component", "cache_warmer").Logger()
+		warmer := routes.NewWarmer(app, warmupInterval, warmupConcurrency, warmerLogger)
+		warmer.Start(ctx)
+		defer warmer.Stop()
+	}
+
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatal().Err(err).Str("port", grpcPort).Msg("unable to listen for gRPC")
+	}
+	grpcServer := routes.NewGRPCServer(app)
+	defer grpcServer.GracefulStop()
+	go func() {
+		log.Info().Msgf("Serving gRPC on port :%s", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatal().Err(err).Msg("gRPC server shutdown")
+		}
+	}()
+
 	log.Info().Msg("Listening on port :8080")
 	if err := http.ListenAndServe(":8080", mux); err != nil {
 		log.Fatal().Err(err).Msg("server shutdown")
 	}
 }
+
+// newAuthenticator builds the API authenticator chain from the environment.
+// Bearer tokens are validated with AUTH_JWT_HMAC_SECRET or the keys in AUTH_JWKS_FILE,
+// and AUTH_API_KEYS holds key=user_id pairs for machine clients.
+func newAuthenticator() auth.Authenticator {
+	var chain auth.Chain
+
+	hmacSecret := os.Getenv("AUTH_JWT_HMAC_SECRET")
+	jwksFile := os.Getenv("AUTH_JWKS_FILE")
+
+	if hmacSecret != "" || jwksFile != "" {
+		cfg := auth.JWTConfig{
+			Issuer:   os.Getenv("AUTH_JWT_ISSUER"),
+			Audience: os.Getenv("AUTH_JWT_AUDIENCE"),
+		}
+		if hmacSecret != "" {
+			cfg.HMACSecret = []byte(hmacSecret)
+		}
+		if jwksFile != "" {
+			jwks, err := auth.LoadJWKS(jwksFile)
+			if err != nil {
+				log.Fatal().Err(err).Str("jwks_file", jwksFile).Msg("failed to load JWKS")
+			}
+			cfg.JWKS = jwks
+		}
+
+		jwtAuth, err := auth.NewJWTAuthenticator(cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid JWT configuration")
+		}
+		// Browsers cannot set headers on EventSource and WebSocket connections
+		chain = append(chain, jwtAuth, auth.NewQueryTokenAuthenticator(jwtAuth, "/stream/", "/ws"))
+	}
+
+	if keys := os.Getenv("AUTH_API_KEYS"); keys != "" {
+		keyAuth, err := auth.ParseAPIKeys(keys)
+		if err != nil {
+			log.Fatal().Err(err).Msg("invalid AUTH_API_KEYS")
+		}
+		chain = append(chain, keyAuth)
+	}
+
+	if len(chain) == 0 {
+		log.Fatal().Msg("AUTH_JWT_HMAC_SECRET, AUTH_JWKS_FILE or AUTH_API_KEYS must be set")
+	}
+
+	return chain
+}
+
+// loadKeyring loads the keys that encrypt MQTT passwords at rest, from the file in
+// MQTT_KEYRING_FILE or inline from MQTT_KEYRING, as id=base64key entries with the primary key first.
+func loadKeyring() *secrets.Keyring {
+	var (
+		keyring *secrets.Keyring
+		err     error
+	)
+
+	switch {
+	case os.Getenv("MQTT_KEYRING_FILE") != "":
+		keyring, err = secrets.LoadKeyring(os.Getenv("MQTT_KEYRING_FILE"))
+	case os.Getenv("MQTT_KEYRING") != "":
+		keyring, err = secrets.ParseKeyring(os.Getenv("MQTT_KEYRING"))
+	default:
+		log.Fatal().Msg("MQTT_KEYRING_FILE or MQTT_KEYRING must be set")
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load MQTT keyring")
+	}
+
+	log.Info().Str("primary_key", keyring.PrimaryID()).Msg("loaded MQTT keyring")
+	return keyring
+}
+
+// newCache picks the driver from CACHE_DRIVER, falling back to whichever of
+// VALKEY_NODES or MEMCACHED_NODE is set. With CACHE_L1=true a remote driver is
+// fronted by an in-process LRU, and remote drivers sit behind a circuit breaker.
+func newCache() (cache.Cache, *routes.Config) {
+	var valkeyAddrs []string
+	if nodes := os.Getenv("VALKEY_NODES"); nodes != "" {
+		valkeyAddrs = strings.Split(nodes, ",")
+	}
+
+	var memcachedAddr string
+	if node := os.Getenv("MEMCACHED_NODE"); node != "" {
+		memcachedAddr = node
+	}
+
+	maxEntries := 10000
+	if v := os.Getenv("CACHE_MEMORY_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatal().Str("value", v).Msg("invalid CACHE_MEMORY_MAX_ENTRIES")
+		}
+		maxEntries = n
+	}
+
+	driver := os.Getenv("CACHE_DRIVER")
+	if driver == "" {
+		if len(valkeyAddrs) > 0 && memcachedAddr != "" {
+			log.Fatal().Msg("only one of VALKEY_NODES or MEMCACHED_NODE may be set")
+		}
+
+		switch {
+		case len(valkeyAddrs) > 0:
+			driver = "valkey"
+		case memcachedAddr != "":
+			driver = "memcached"
+		default:
+			log.Fatal().Msg("CACHE_DRIVER, VALKEY_NODES or MEMCACHED_NODE must be set")
+		}
+	}
+
+	var c cache.Cache
+	switch driver {
+	case "valkey":
+		if len(valkeyAddrs) == 0 {
+			log.Fatal().Msg("VALKEY_NODES must be set for the valkey cache driver")
+		}
+		c = cache.NewValkey(valkeyAddrs)
+		log.Info().Msg("using Valkey cache driver")
+	case "memcached":
+		if memcachedAddr == "" {
+			log.Fatal().Msg("MEMCACHED_NODE must be set for the memcached cache driver")
+		}
+		c = cache.NewMemcached(memcachedAddr)
+		log.Info().Msg("using Memcached cache driver")
+	case "memory":
+		log.Info().Int("max_entries", maxEntries).Msg("using in-process memory cache driver")
+		return cache.NewMemory(maxEntries), routes.NewConfig(driver)
+	default:
+		log.Fatal().Str("driver", driver).Msg("unknown CACHE_DRIVER, expected valkey, memcached or memory")
+	}
+
+	if l1, _ := strconv.ParseBool(os.Getenv("CACHE_L1")); l1 {
+		l1TTL := 30 * time.Second
+		if v := os.Getenv("CACHE_L1_TTL"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				log.Fatal().Str("value", v).Msg("invalid CACHE_L1_TTL")
+			}
+			l1TTL = d
+		}
+
+		c = cache.NewTiered(cache.NewMemory(maxEntries), c, l1TTL)
+		log.Info().Int("max_entries", maxEntries).Dur("l1_ttl", l1TTL).Msg("enabled in-process L1 cache")
+	}
+
+	threshold := 5
+	if v := os.Getenv("CACHE_BREAKER_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatal().Str("value", v).Msg("invalid CACHE_BREAKER_THRESHOLD")
+		}
+		threshold = n
+	}
+
+	cooldown := 10 * time.Second
+	if v := os.Getenv("CACHE_BREAKER_COOLDOWN"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatal().Str("value", v).Msg("invalid CACHE_BREAKER_COOLDOWN")
+		}
+		cooldown = d
+	}
+
+	breakerLogger := log.Logger.With().Str("component", "cache_breaker").Logger()
+	c = cache.NewBreaker(c, driver, threshold, cooldown, breakerLogger)
+
+	return c, routes.NewConfig(driver)
+}
+
+// newCodec reads CACHE_FORMAT (json or msgpack, default msgpack) and CACHE_COMPRESSION
+// (none, zstd or snappy, default zstd). Compression only applies to large values.
+func newCodec() *cache.Codec {
+	formatEnv := os.Getenv("CACHE_FORMAT")
+	if formatEnv == "" {
+		formatEnv = "msgpack"
+	}
+	format, err := cache.ParseFormat(formatEnv)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid CACHE_FORMAT")
+	}
+
+	compressionEnv := os.Getenv("CACHE_COMPRESSION")
+	if compressionEnv == "" {
+		compressionEnv = "zstd"
+	}
+	compression, err := cache.ParseCompression(compressionEnv)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid CACHE_COMPRESSION")
+	}
+
+	log.Info().Str("format", formatEnv).Str("compression", compressionEnv).Msg("configured cache codec")
+	return cache.NewCodec(format, compression)
+}
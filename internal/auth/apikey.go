@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator authenticates machine clients by a static key mapped to a user.
+type APIKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]uuid.UUID
+}
+
+// ParseAPIKeys parses a comma separated list of key=user_id pairs.
+func ParseAPIKeys(raw string) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{
+		keys: make(map[[sha256.Size]byte]uuid.UUID),
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, user, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid api key entry, expected key=user_id")
+		}
+
+		userID, err := uuid.Parse(user)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_id for api key: %w", err)
+		}
+
+		a.keys[sha256.Sum256([]byte(key))] = userID
+	}
+
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// Compare digests in constant time so lookups leak nothing about stored keys
+	sum := sha256.Sum256([]byte(key))
+	for k, userID := range a.keys {
+		if subtle.ConstantTimeCompare(k[:], sum[:]) == 1 {
+			return &Principal{
+				UserID: userID,
+				Method: MethodAPIKey,
+			}, nil
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,90 @@
+// Package auth authenticates API callers and carries the resulting principal through the request context.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+var (
+	// ErrNoCredentials means the request carried nothing this authenticator understands.
+	ErrNoCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials means credentials were present but rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID uuid.UUID
+	Method Method
+}
+
+// Authenticator resolves the principal of a request.
+// It returns ErrNoCredentials when the request carries no credentials it handles,
+// so several authenticators can be chained.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries each authenticator in order and returns the first principal found.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal injected by Middleware.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Middleware rejects unauthenticated requests and injects the principal into the request context.
+func Middleware(authn Authenticator, logger zerolog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// CORS preflights carry no credentials
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			p, err := authn.Authenticate(r)
+			if err != nil {
+				logger.Warn().Err(err).Str("path", r.URL.Path).Msg("authentication failed")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="nostradamus-api"`)
+				utils.ReplyUnauthorized(w, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// JWTConfig configures bearer token validation. Exactly one of HMACSecret or JWKS must be set.
+type JWTConfig struct {
+	HMACSecret []byte
+	JWKS       *JWKS
+	Issuer     string
+	Audience   string
+}
+
+// JWTAuthenticator validates bearer tokens whose subject is the caller's user_id.
+type JWTAuthenticator struct {
+	parser  *jwt.Parser
+	keyFunc jwt.Keyfunc
+}
+
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	var keyFunc jwt.Keyfunc
+	switch {
+	case cfg.HMACSecret != nil && cfg.JWKS != nil:
+		return nil, fmt.Errorf("only one of HMAC secret or JWKS may be set")
+	case cfg.HMACSecret != nil:
+		opts = append(opts, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+		keyFunc = func(*jwt.Token) (any, error) {
+			return cfg.HMACSecret, nil
+		}
+	case cfg.JWKS != nil:
+		opts = append(opts, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+		keyFunc = cfg.JWKS.Keyfunc
+	default:
+		return nil, fmt.Errorf("one of HMAC secret or JWKS must be set")
+	}
+
+	return &JWTAuthenticator{
+		parser:  jwt.NewParser(opts...),
+		keyFunc: keyFunc,
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	header := r.Header.Get("Authorization")
+	raw, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || raw == "" {
+		return nil, ErrNoCredentials
+	}
+
+	var claims jwt.RegisteredClaims
+	if _, err := a.parser.ParseWithClaims(raw, &claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject is not a user_id", ErrInvalidCredentials)
+	}
+
+	return &Principal{
+		UserID: userID,
+		Method: MethodJWT,
+	}, nil
+}
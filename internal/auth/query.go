@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// QueryTokenParam carries a bearer token for clients that cannot set headers, like
+// the browser EventSource and WebSocket APIs.
+const QueryTokenParam = "access_token"
+
+// QueryTokenAuthenticator accepts a bearer token from the access_token query param, but
+// only on GET requests under the given path prefixes, so tokens stay out of URLs elsewhere.
+type QueryTokenAuthenticator struct {
+	next     Authenticator
+	prefixes []string
+}
+
+func NewQueryTokenAuthenticator(next Authenticator, prefixes ...string) *QueryTokenAuthenticator {
+	return &QueryTokenAuthenticator{
+		next:     next,
+		prefixes: prefixes,
+	}
+}
+
+func (a *QueryTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := r.URL.Query().Get(QueryTokenParam)
+	if token == "" || r.Method != http.MethodGet || !a.allowed(r.URL.Path) {
+		return nil, ErrNoCredentials
+	}
+
+	clone := r.Clone(r.Context())
+	clone.Header.Set("Authorization", "Bearer "+token)
+	return a.next.Authenticate(clone)
+}
+
+func (a *QueryTokenAuthenticator) allowed(path string) bool {
+	for _, prefix := range a.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+var _ Cache = (*Breaker)(nil)
+
+// ErrCircuitOpen is returned without calling the cache while the breaker is open.
+var ErrCircuitOpen = errors.New("cache circuit open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half_open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker is a circuit breaker around a Cache. After threshold consecutive failures it
+// opens and fails every call with ErrCircuitOpen, so callers go straight to the database.
+// Once cooldown has passed a single probe call is let through; its outcome closes or
+// reopens the circuit. Misses and calls cancelled by the caller are not failures.
+type Breaker struct {
+	cache     Cache
+	driver    string
+	threshold int
+	cooldown  time.Duration
+	logger    zerolog.Logger
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(c Cache, driver string, threshold int, cooldown time.Duration, logger zerolog.Logger) *Breaker {
+	metrics.CacheCircuitState.WithLabelValues(driver).Set(float64(BreakerClosed))
+
+	return &Breaker{
+		cache:     c,
+		driver:    driver,
+		threshold: threshold,
+		cooldown:  cooldown,
+		logger:    logger,
+	}
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// setState must be called with mu held.
+func (b *Breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	b.logger.Warn().Str("from", b.state.String()).Str("to", state.String()).Msg("cache circuit state changed")
+	b.state = state
+	metrics.CacheCircuitState.WithLabelValues(b.driver).Set(float64(state))
+
+	if state == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) record(ctx context.Context, err error) {
+	cancelled := ctx.Err() != nil
+	failed := err != nil && !errors.Is(err, ErrMiss) && !cancelled
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		switch {
+		case cancelled:
+			// Inconclusive, let the next call probe again
+		case failed:
+			b.setState(BreakerOpen)
+		default:
+			b.failures = 0
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	if !failed {
+		if !cancelled {
+			b.failures = 0
+		}
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *Breaker) do(ctx context.Context, fn func() error) error {
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := fn()
+	b.record(ctx, err)
+	return err
+}
+
+func (b *Breaker) Store(ctx context.Context, prefix string, entry types.Entry) error {
+	return b.do(ctx, func() error {
+		return b.cache.Store(ctx, prefix, entry)
+	})
+}
+
+func (b *Breaker) StoreMany(ctx context.Context, key string, entries []types.Entry, ttl time.Duration) error {
+	return b.do(ctx, func() error {
+		return b.cache.StoreMany(ctx, key, entries, ttl)
+	})
+}
+
+func (b *Breaker) FetchLast(ctx context.Context, prefix string, n int) ([]types.Entry, error) {
+	var entries []types.Entry
+	err := b.do(ctx, func() (err error) {
+		entries, err = b.cache.FetchLast(ctx, prefix, n)
+		return err
+	})
+	return entries, err
+}
+
+func (b *Breaker) StoreAggregate(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return b.do(ctx, func() error {
+		return b.cache.StoreAggregate(ctx, key, data, ttl)
+	})
+}
+
+func (b *Breaker) FetchAggregate(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := b.do(ctx, func() (err error) {
+		data, err = b.cache.FetchAggregate(ctx, key)
+		return err
+	})
+	return data, err
+}
+
+func (b *Breaker) Incr(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := b.do(ctx, func() (err error) {
+		n, err = b.cache.Incr(ctx, key)
+		return err
+	})
+	return n, err
+}
+
+func (b *Breaker) Delete(ctx context.Context, keys ...string) error {
+	return b.do(ctx, func() error {
+		return b.cache.Delete(ctx, keys...)
+	})
+}
+
+// Ping always reaches the cache, so readiness reflects the cache itself.
+func (b *Breaker) Ping(ctx context.Context) error {
+	return b.cache.Ping(ctx)
+}
+
+func (b *Breaker) Close() {
+	b.cache.Close()
+}
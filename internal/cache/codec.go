@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// EncodingVersion is written in the header of every value stored through a Codec.
+// Bump it whenever the layout of cached types changes; older entries then read as misses.
+const EncodingVersion byte = 1
+
+// headerSize is version, format and compression, one byte each.
+const headerSize = 3
+
+// minCompressSize keeps small values, like single aggregates, uncompressed.
+const minCompressSize = 1024
+
+type Format byte
+
+const (
+	FormatJSON Format = iota + 1
+	FormatMsgpack
+)
+
+type Compression byte
+
+const (
+	CompressionNone Compression = iota
+	CompressionZstd
+	CompressionSnappy
+)
+
+// ErrCorrupt is returned for values that cannot be decoded.
+var ErrCorrupt = errors.New("corrupt cache entry")
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// Codec encodes typed values with a small header so the format and compression can
+// change without flushing the cache. Decoding honours the header, not the codec's
+// own settings, so replicas with different settings can share a cache.
+type Codec struct {
+	format      Format
+	compression Compression
+}
+
+func NewCodec(format Format, compression Compression) *Codec {
+	return &Codec{format, compression}
+}
+
+// ParseFormat accepts json or msgpack.
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "json":
+		return FormatJSON, nil
+	case "msgpack":
+		return FormatMsgpack, nil
+	default:
+		return 0, fmt.Errorf("unknown cache format %q, expected json or msgpack", s)
+	}
+}
+
+// ParseCompression accepts none, zstd or snappy.
+func ParseCompression(s string) (Compression, error) {
+	switch s {
+	case "none":
+		return CompressionNone, nil
+	case "zstd":
+		return CompressionZstd, nil
+	case "snappy":
+		return CompressionSnappy, nil
+	default:
+		return 0, fmt.Errorf("unknown cache compression %q, expected none, zstd or snappy", s)
+	}
+}
+
+func (c *Codec) Encode(v any) ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+	switch c.format {
+	case FormatMsgpack:
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		// Cached types are API types, reuse their json tags
+		enc.SetCustomStructTag("json")
+		err = enc.Encode(v)
+		payload = buf.Bytes()
+	default:
+		payload, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	compression := c.compression
+	if len(payload) < minCompressSize {
+		compression = CompressionNone
+	}
+
+	switch compression {
+	case CompressionZstd:
+		payload = zstdEncoder.EncodeAll(payload, nil)
+	case CompressionSnappy:
+		payload = snappy.Encode(nil, payload)
+	}
+
+	return append([]byte{EncodingVersion, byte(c.format), byte(compression)}, payload...), nil
+}
+
+// Decode reads a value written by Encode into v. Entries from another EncodingVersion
+// are reported as ErrMiss.
+func (c *Codec) Decode(b []byte, v any) error {
+	if len(b) < headerSize {
+		return ErrCorrupt
+	}
+	if b[0] != EncodingVersion {
+		return ErrMiss
+	}
+
+	payload := b[headerSize:]
+
+	var err error
+	switch Compression(b[2]) {
+	case CompressionNone:
+	case CompressionZstd:
+		payload, err = zstdDecoder.DecodeAll(payload, nil)
+	case CompressionSnappy:
+		payload, err = snappy.Decode(nil, payload)
+	default:
+		return fmt.Errorf("%w: unknown compression %d", ErrCorrupt, b[2])
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	switch Format(b[1]) {
+	case FormatJSON:
+		err = json.Unmarshal(payload, v)
+	case FormatMsgpack:
+		dec := msgpack.NewDecoder(bytes.NewReader(payload))
+		dec.SetCustomStructTag("json")
+		err = dec.Decode(v)
+	default:
+		return fmt.Errorf("%w: unknown format %d", ErrCorrupt, b[1])
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	return nil
+}
+
+// Get fetches and decodes a typed value. Misses, including entries written with another
+// EncodingVersion, return ErrMiss.
+func Get[T any](ctx context.Context, c Cache, codec *Codec, key string) (T, error) {
+	var v T
+
+	b, err := c.FetchAggregate(ctx, key)
+	if err != nil {
+		return v, err
+	}
+
+	err = codec.Decode(b, &v)
+	return v, err
+}
+
+// Set encodes and stores a typed value.
+func Set(ctx context.Context, c Cache, codec *Codec, key string, v any, ttl time.Duration) error {
+	b, err := codec.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	return c.StoreAggregate(ctx, key, b, ttl)
+}
@@ -2,26 +2,50 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/ntentasd/nostradamus-api/pkg/types"
 )
 
+const (
+	// MaxLatestEntries bounds how many readings a time-series key keeps.
+	MaxLatestEntries = 512
+	// LatestTTL is the expiry of time-series keys written through Store.
+	LatestTTL = time.Hour
+)
+
+// ErrMiss is returned when a key is not cached.
+var ErrMiss = errors.New("cache miss")
+
 // Cache defines the general caching for the api.
 // It abstracts time-series (ZSET) and key-values (SET).
 type Cache interface {
 	// Store stores a single reading (usually time-series data)
-	Store(prefix string, entry types.Entry) error
+	Store(ctx context.Context, prefix string, entry types.Entry) error
+
+	// StoreMany stores several readings in one round trip, keeping at most
+	// MaxLatestEntries of the newest ones
+	StoreMany(ctx context.Context, key string, entries []types.Entry, ttl time.Duration) error
 
 	// FetchLast retrieves the N most recent entries from a sorted cache
-	FetchLast(prefix string, n int) ([]types.Entry, error)
+	FetchLast(ctx context.Context, prefix string, n int) ([]types.Entry, error)
 
-	// StoreAggregate caches a computed aggregate with a TTL
-	StoreAggregate(ctx context.Context, key string, data any, ttl time.Duration) error
+	// StoreAggregate caches an encoded value with a TTL, zero means no expiry.
+	// Use Set to encode typed values
+	StoreAggregate(ctx context.Context, key string, data []byte, ttl time.Duration) error
 
-	// FetchAggregate retrieves an aggregate from cache
+	// FetchAggregate retrieves an encoded value from cache, ErrMiss if absent.
+	// Use Get to decode typed values
 	FetchAggregate(ctx context.Context, key string) ([]byte, error)
 
+	// Incr atomically adds one to the decimal counter at key and returns the result.
+	// A missing key counts from zero and does not expire
+	Incr(ctx context.Context, key string) (int64, error)
+
+	// Delete removes keys, missing keys are not an error
+	Delete(ctx context.Context, keys ...string) error
+
 	// Ping checks cache connection
 	Ping(ctx context.Context) error
 
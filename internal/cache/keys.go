@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultNamespace prefixes every key unless CACHE_NAMESPACE says otherwise.
+const DefaultNamespace = "nostradamus"
+
+// Keys builds every cache key of the API. Keys are laid out as
+//
+//	<namespace>:v<version>:{<sensor_id>}:<kind>:...
+//
+// The hash tag keeps all keys of a sensor on the same Valkey cluster slot, and bumping
+// the version orphans every existing key at once, e.g. after a format change.
+type Keys struct {
+	namespace string
+	version   atomic.Int64
+}
+
+func NewKeys(namespace string) *Keys {
+	return &Keys{namespace: namespace}
+}
+
+func (k *Keys) Version() int64 {
+	return k.version.Load()
+}
+
+func (k *Keys) sensor(sensorID string) string {
+	return fmt.Sprintf("%s:v%d:{%s}", k.namespace, k.version.Load(), sensorID)
+}
+
+// Latest is the time-series key holding the most recent readings of a sensor.
+func (k *Keys) Latest(sensorID string, sType int) string {
+	return fmt.Sprintf("%s:latest:%d", k.sensor(sensorID), sType)
+}
+
+// Aggregate holds a window aggregate computed on day under the given generation.
+// Requested optional stats are part of the key, so they must be sorted.
+func (k *Keys) Aggregate(sensorID string, sType int, day time.Time, window string, gen int64, stats []string) string {
+	key := fmt.Sprintf("%s:agg:%d:%s:%s:%d", k.sensor(sensorID), sType, day.Format("2006-01-02"), window, gen)
+	if len(stats) > 0 {
+		key += ":" + strings.Join(stats, ",")
+	}
+	return key
+}
+
+// DaySummary holds the mergeable summary of one completed UTC day of readings.
+func (k *Keys) DaySummary(sensorID string, sType int, day time.Time) string {
+	return fmt.Sprintf("%s:day:%d:%s", k.sensor(sensorID), sType, day.Format("2006-01-02"))
+}
+
+// Series holds one chunk of an aggregate series, aligned to step.
+func (k *Keys) Series(sensorID string, sType int, step time.Duration, chunk time.Time) string {
+	return fmt.Sprintf("%s:series:%d:%s:%d", k.sensor(sensorID), sType, step, chunk.Unix())
+}
+
+// UnknownSensor marks a sensor ID that was recently looked up and not found.
+func (k *Keys) UnknownSensor(sensorID string) string {
+	return fmt.Sprintf("%s:unknown", k.sensor(sensorID))
+}
+
+// AggregateGeneration holds the generation of a sensor's window aggregates. It is
+// bumped whenever new readings arrive, which orphans every aggregate cached under the
+// previous generation.
+func (k *Keys) AggregateGeneration(sensorID string) string {
+	return k.sensor(sensorID) + generationSuffix
+}
+
+// versionKey is shared by all versions and never expires. It holds the version as
+// plain decimal so it stays readable whatever the Codec settings.
+func (k *Keys) versionKey() string {
+	return k.namespace + versionSuffix
+}
+
+const (
+	generationSuffix = ":gen"
+	versionSuffix    = ":version"
+)
+
+// sharedKey reports whether key holds a generation or the version, which every replica
+// must see as soon as it changes. Tiered never keeps such keys in L1.
+func sharedKey(key string) bool {
+	return strings.HasSuffix(key, generationSuffix) || strings.HasSuffix(key, versionSuffix)
+}
+
+// Load reads the current version from c. A missing version keeps the local one and
+// writes it back, so an evicted version key does not reset the namespace.
+func (k *Keys) Load(ctx context.Context, c Cache) error {
+	b, err := c.FetchAggregate(ctx, k.versionKey())
+	if errors.Is(err, ErrMiss) {
+		return c.StoreAggregate(ctx, k.versionKey(), strconv.AppendInt(nil, k.version.Load(), 10), 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid cache version: %w", err)
+	}
+
+	k.version.Store(version)
+	return nil
+}
+
+// Bump moves the namespace to the next version and returns it. The version is
+// incremented atomically, so concurrent bumps each get a new version. Other replicas
+// pick it up on their next Sync.
+func (k *Keys) Bump(ctx context.Context, c Cache) (int64, error) {
+	// Restores an evicted version key first, so the increment does not restart at 1
+	if err := k.Load(ctx, c); err != nil {
+		return 0, err
+	}
+
+	version, err := c.Incr(ctx, k.versionKey())
+	if err != nil {
+		return 0, fmt.Errorf("failed to bump cache version: %w", err)
+	}
+
+	k.version.Store(version)
+	return version, nil
+}
+
+// Sync keeps the local version in step with the cache until ctx is done.
+func (k *Keys) Sync(ctx context.Context, c Cache, interval time.Duration, logger zerolog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := k.Load(ctx, c); err != nil {
+			logger.Warn().Err(err).Msg("failed to load cache version")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
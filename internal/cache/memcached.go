@@ -1,9 +1,13 @@
 package cache
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -26,30 +30,204 @@ func NewMemcached(addr string) *Memcached {
 	return &Memcached{client, cm}
 }
 
-func (m *Memcached) store(key string, val []byte, ttl time.Duration) error {
-	done := make(chan error, 1)
+// memcachedTimeout bounds every Memcached operation, so a slow server fails calls quickly
+// and trips the Breaker instead of holding callers past their deadline.
+const memcachedTimeout = 100 * time.Millisecond
+
+// withTimeout runs op, giving up after memcachedTimeout or when ctx is done. The client
+// call itself keeps running in the background until it completes.
+func withTimeout[T any](ctx context.Context, op func() (T, error)) (T, error) {
+	type result struct {
+		val T
+		err error
+	}
+
+	done := make(chan result, 1)
 	go func() {
-		done <- m.client.Set(&memcache.Item{Key: key, Value: val, Expiration: int32(ttl.Seconds())})
+		val, err := op()
+		done <- result{val, err}
 	}()
+
+	ctx, cancel := context.WithTimeout(ctx, memcachedTimeout)
+	defer cancel()
+
 	select {
-	case err := <-done:
-		return err
-	case <-time.After(100 * time.Millisecond):
-		return context.DeadlineExceeded
+	case res := <-done:
+		return res.val, res.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
 	}
 }
 
-func (m *Memcached) Store(prefix string, entry types.Entry) error {
-	// unimplemented
-	return nil
+// exec is withTimeout for operations without a result.
+func exec(ctx context.Context, op func() error) error {
+	_, err := withTimeout(ctx, func() (struct{}, error) {
+		return struct{}{}, op()
+	})
+	return err
+}
+
+func (m *Memcached) get(ctx context.Context, key string) (*memcache.Item, error) {
+	return withTimeout(ctx, func() (*memcache.Item, error) {
+		return m.client.Get(key)
+	})
+}
+
+func (m *Memcached) store(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	return exec(ctx, func() error {
+		return m.client.Set(&memcache.Item{Key: key, Value: val, Expiration: int32(ttl.Seconds())})
+	})
+}
+
+const (
+	// ringEntrySize is the encoded size of one reading: unix millis + float64 bits.
+	ringEntrySize = 16
+	// casRetries bounds the optimistic append loop under contention.
+	casRetries = 5
+)
+
+// encodeRing serializes entries, newest first, as fixed-size big-endian records.
+func encodeRing(entries []types.Entry) []byte {
+	b := make([]byte, len(entries)*ringEntrySize)
+	for i, e := range entries {
+		off := i * ringEntrySize
+		binary.BigEndian.PutUint64(b[off:], uint64(e.Timestamp.UnixMilli()))
+		binary.BigEndian.PutUint64(b[off+8:], math.Float64bits(e.Value))
+	}
+	return b
+}
+
+func decodeRing(b []byte) ([]types.Entry, error) {
+	if len(b)%ringEntrySize != 0 {
+		return nil, fmt.Errorf("corrupt ring buffer of %d bytes", len(b))
+	}
+
+	entries := make([]types.Entry, 0, len(b)/ringEntrySize)
+	for off := 0; off < len(b); off += ringEntrySize {
+		entries = append(entries, types.Entry{
+			Timestamp: time.UnixMilli(int64(binary.BigEndian.Uint64(b[off:]))),
+			Value:     math.Float64frombits(binary.BigEndian.Uint64(b[off+8:])),
+		})
+	}
+	return entries, nil
+}
+
+// insertRing adds entry keeping the buffer ordered by timestamp, newest first, like a ZSET
+// scored by timestamp. An entry with the same timestamp is replaced, and the oldest
+// entries are dropped beyond MaxLatestEntries.
+func insertRing(entries []types.Entry, entry types.Entry) []types.Entry {
+	ts := entry.Timestamp.UnixMilli()
+	i, found := slices.BinarySearchFunc(entries, ts, func(e types.Entry, t int64) int {
+		return cmp.Compare(t, e.Timestamp.UnixMilli())
+	})
+
+	if found {
+		entries[i] = entry
+	} else {
+		entries = slices.Insert(entries, i, entry)
+	}
+
+	if len(entries) > MaxLatestEntries {
+		entries = entries[:MaxLatestEntries]
+	}
+	return entries
 }
 
-func (m *Memcached) FetchLast(prefix string, n int) ([]types.Entry, error) {
-	// unimplemented
-	return nil, nil
+// mergeRing inserts every entry into the buffer, see insertRing.
+func mergeRing(ring []types.Entry, entries []types.Entry) []types.Entry {
+	for _, e := range entries {
+		ring = insertRing(ring, e)
+	}
+	return ring
+}
+
+func (m *Memcached) Store(ctx context.Context, prefix string, entry types.Entry) error {
+	return m.StoreMany(ctx, prefix, []types.Entry{entry}, LatestTTL)
 }
 
-func (m *Memcached) StoreAggregate(ctx context.Context, key string, data any, ttl time.Duration) error {
+// StoreMany merges entries into the ring buffer at key using check-and-set, retrying on conflicts.
+func (m *Memcached) StoreMany(ctx context.Context, key string, entries []types.Entry, ttl time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	expiration := int32(ttl.Seconds())
+	start := time.Now()
+
+	for attempt := 0; attempt < casRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		item, err := m.get(ctx, key)
+		switch {
+		case err == memcache.ErrCacheMiss:
+			err = exec(ctx, func() error {
+				return m.client.Add(&memcache.Item{
+					Key:        key,
+					Value:      encodeRing(mergeRing(nil, entries)),
+					Expiration: expiration,
+				})
+			})
+			if err == memcache.ErrNotStored {
+				// Someone else created the key first, append to theirs
+				continue
+			}
+			if err == nil {
+				m.metrics.RecordWrite(start)
+			}
+			return err
+		case err != nil:
+			return err
+		}
+
+		existing, err := decodeRing(item.Value)
+		if err != nil {
+			// Overwrite a corrupt buffer instead of failing forever
+			existing = nil
+		}
+
+		item.Value = encodeRing(mergeRing(existing, entries))
+		item.Expiration = expiration
+
+		err = exec(ctx, func() error {
+			return m.client.CompareAndSwap(item)
+		})
+		if err == memcache.ErrCASConflict || err == memcache.ErrNotStored {
+			continue
+		}
+		if err == nil {
+			m.metrics.RecordWrite(start)
+		}
+		return err
+	}
+
+	return fmt.Errorf("store %s: too much contention after %d attempts", key, casRetries)
+}
+
+// FetchLast returns up to n of the most recent readings at prefix, newest first.
+func (m *Memcached) FetchLast(ctx context.Context, prefix string, n int) ([]types.Entry, error) {
+	item, err := m.get(ctx, prefix)
+	if err == memcache.ErrCacheMiss {
+		return []types.Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := decodeRing(item.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
+
+func (m *Memcached) StoreAggregate(ctx context.Context, key string, data []byte, ttl time.Duration) error {
 	ctx, span := otel.Tracer("nostradamus-cache").Start(ctx, "cache.StoreAggregate")
 	defer span.End()
 
@@ -59,15 +237,8 @@ func (m *Memcached) StoreAggregate(ctx context.Context, key string, data any, tt
 		attribute.Int64("cache.ttl", int64(ttl.Seconds())),
 	)
 
-	b, err := json.Marshal(data)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to marshal aggregate: %w", err)
-	}
-
 	start := time.Now()
-	if err := m.store(key, b, ttl); err != nil {
+	if err := m.store(ctx, key, data, ttl); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to store aggregate: %w", err)
@@ -88,13 +259,13 @@ func (m *Memcached) FetchAggregate(ctx context.Context, key string) ([]byte, err
 	)
 
 	start := time.Now()
-	val, err := m.client.Get(key)
+	val, err := m.get(ctx, key)
 	switch {
 	case err == memcache.ErrCacheMiss:
 		m.metrics.RecordMiss()
 		span.SetAttributes(attribute.String("cache.result", "miss"))
 		span.SetStatus(codes.Ok, "")
-		return nil, fmt.Errorf("cache miss")
+		return nil, ErrMiss
 	case err != nil:
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -107,8 +278,57 @@ func (m *Memcached) FetchAggregate(ctx context.Context, key string) ([]byte, err
 	}
 }
 
+// Incr increments key in place, creating it when missing. Memcached does not create
+// counters on increment, so a racing creation is retried as an increment.
+func (m *Memcached) Incr(ctx context.Context, key string) (int64, error) {
+	for attempt := 0; attempt < casRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		n, err := withTimeout(ctx, func() (uint64, error) {
+			return m.client.Increment(key, 1)
+		})
+		if err == nil {
+			return int64(n), nil
+		}
+		if err != memcache.ErrCacheMiss {
+			return 0, err
+		}
+
+		err = exec(ctx, func() error {
+			return m.client.Add(&memcache.Item{Key: key, Value: []byte("1")})
+		})
+		if err == nil {
+			return 1, nil
+		}
+		if err != memcache.ErrNotStored {
+			return 0, err
+		}
+	}
+
+	return 0, fmt.Errorf("incr %s: too much contention after %d attempts", key, casRetries)
+}
+
+func (m *Memcached) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		err := exec(ctx, func() error {
+			return m.client.Delete(key)
+		})
+		if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Memcached) setTier(tier string) {
+	m.metrics.tier = tier
+}
+
 func (m *Memcached) Ping(ctx context.Context) error {
-	return m.client.Ping()
+	return exec(ctx, m.client.Ping)
 }
 
 func (m *Memcached) Close() {
@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var _ Cache = (*Memory)(nil)
+
+// Memory is an in-process, size-bounded LRU cache with per-key TTLs.
+// Time-series keys and aggregates share the same capacity.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	metrics    *CacheMetrics
+}
+
+type memoryItem struct {
+	key     string
+	value   []byte
+	entries []types.Entry
+	// expiresAt is zero for items without a TTL
+	expiresAt time.Time
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		metrics:    NewCacheMetrics("memory"),
+	}
+}
+
+func (m *Memory) setTier(tier string) {
+	m.metrics.tier = tier
+}
+
+// get returns a live item and marks it as recently used. Must be called with mu held.
+func (m *Memory) get(key string) (*memoryItem, bool) {
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := el.Value.(*memoryItem)
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		m.ll.Remove(el)
+		delete(m.items, key)
+		return nil, false
+	}
+
+	m.ll.MoveToFront(el)
+	return item, true
+}
+
+// put inserts or replaces an item, evicting the least recently used beyond capacity.
+// Must be called with mu held.
+func (m *Memory) put(item *memoryItem) {
+	if el, ok := m.items[item.key]; ok {
+		el.Value = item
+		m.ll.MoveToFront(el)
+		return
+	}
+
+	m.items[item.key] = m.ll.PushFront(item)
+
+	for m.maxEntries > 0 && m.ll.Len() > m.maxEntries {
+		oldest := m.ll.Back()
+		m.ll.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+func (m *Memory) Store(ctx context.Context, prefix string, entry types.Entry) error {
+	return m.StoreMany(ctx, prefix, []types.Entry{entry}, LatestTTL)
+}
+
+func (m *Memory) StoreMany(ctx context.Context, key string, entries []types.Entry, ttl time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := time.Now()
+
+	var existing []types.Entry
+	if item, ok := m.get(key); ok {
+		existing = item.entries
+	}
+
+	m.put(&memoryItem{
+		key:       key,
+		entries:   mergeRing(existing, entries),
+		expiresAt: expiry(ttl),
+	})
+	m.metrics.RecordWrite(start)
+
+	return nil
+}
+
+func (m *Memory) FetchLast(ctx context.Context, prefix string, n int) ([]types.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.get(prefix)
+	if !ok {
+		return []types.Entry{}, nil
+	}
+
+	// Copy so callers never alias the cached slice
+	return append([]types.Entry(nil), item.entries[:min(n, len(item.entries))]...), nil
+}
+
+func (m *Memory) StoreAggregate(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	_, span := otel.Tracer("nostradamus-cache").Start(ctx, "cache.StoreAggregate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("cache.driver", "memory"),
+		attribute.String("cache.key", key),
+		attribute.Int64("cache.ttl", int64(ttl.Seconds())),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := time.Now()
+	m.put(&memoryItem{
+		key:       key,
+		value:     data,
+		expiresAt: expiry(ttl),
+	})
+	m.metrics.RecordWrite(start)
+	span.SetStatus(codes.Ok, "")
+
+	return nil
+}
+
+func (m *Memory) FetchAggregate(ctx context.Context, key string) ([]byte, error) {
+	_, span := otel.Tracer("nostradamus-cache").Start(ctx, "cache.FetchAggregate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("cache.driver", "memory"),
+		attribute.String("cache.key", key),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := time.Now()
+	item, ok := m.get(key)
+	if !ok || item.value == nil {
+		m.metrics.RecordMiss()
+		span.SetAttributes(attribute.String("cache.result", "miss"))
+		span.SetStatus(codes.Ok, "")
+		return nil, ErrMiss
+	}
+
+	m.metrics.RecordHit(start)
+	span.SetAttributes(attribute.String("cache.result", "hit"))
+	span.SetStatus(codes.Ok, "")
+	return item.value, nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	if item, ok := m.get(key); ok && item.value != nil {
+		var err error
+		n, err = strconv.ParseInt(string(item.value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("incr %s: %w", key, err)
+		}
+	}
+	n++
+
+	m.put(&memoryItem{
+		key:   key,
+		value: strconv.AppendInt(nil, n, 10),
+	})
+	return n, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := m.items[key]; ok {
+			m.ll.Remove(el)
+			delete(m.items, key)
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *Memory) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ll.Init()
+	clear(m.items)
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+var _ Cache = (*Tiered)(nil)
+
+const (
+	TierPrimary = "primary"
+	TierL1      = "l1"
+	TierL2      = "l2"
+)
+
+// tiered is implemented by drivers whose metrics can be relabelled when layered.
+type tiered interface {
+	setTier(tier string)
+}
+
+// Tiered layers an in-process L1 in front of a remote L2. Aggregates are served from L1
+// when possible and backfilled on L2 hits; time-series calls, aggregate generations and
+// the key version go straight to L2, which is shared by every API replica.
+type Tiered struct {
+	l1    Cache
+	l2    Cache
+	l1TTL time.Duration
+}
+
+// NewTiered layers l1 over l2. Entries are kept in L1 for at most l1TTL, which bounds
+// how stale an aggregate can be relative to L2.
+func NewTiered(l1, l2 Cache, l1TTL time.Duration) *Tiered {
+	if t, ok := l1.(tiered); ok {
+		t.setTier(TierL1)
+	}
+	if t, ok := l2.(tiered); ok {
+		t.setTier(TierL2)
+	}
+
+	return &Tiered{
+		l1:    l1,
+		l2:    l2,
+		l1TTL: l1TTL,
+	}
+}
+
+func (t *Tiered) Store(ctx context.Context, prefix string, entry types.Entry) error {
+	return t.l2.Store(ctx, prefix, entry)
+}
+
+func (t *Tiered) StoreMany(ctx context.Context, key string, entries []types.Entry, ttl time.Duration) error {
+	return t.l2.StoreMany(ctx, key, entries, ttl)
+}
+
+func (t *Tiered) FetchLast(ctx context.Context, prefix string, n int) ([]types.Entry, error) {
+	return t.l2.FetchLast(ctx, prefix, n)
+}
+
+func (t *Tiered) StoreAggregate(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	if err := t.l2.StoreAggregate(ctx, key, data, ttl); err != nil {
+		return err
+	}
+	if sharedKey(key) {
+		return nil
+	}
+
+	// Keys without a TTL still expire from L1, so changes from other replicas show up
+	l1TTL := t.l1TTL
+	if ttl > 0 {
+		l1TTL = min(ttl, t.l1TTL)
+	}
+	return t.l1.StoreAggregate(ctx, key, data, l1TTL)
+}
+
+func (t *Tiered) FetchAggregate(ctx context.Context, key string) ([]byte, error) {
+	if sharedKey(key) {
+		return t.l2.FetchAggregate(ctx, key)
+	}
+
+	if b, err := t.l1.FetchAggregate(ctx, key); err == nil && b != nil {
+		return b, nil
+	}
+
+	b, err := t.l2.FetchAggregate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = t.l1.StoreAggregate(ctx, key, b, t.l1TTL)
+	return b, nil
+}
+
+// Incr counts in L2 and drops the L1 copy, which would otherwise hide the new value.
+func (t *Tiered) Incr(ctx context.Context, key string) (int64, error) {
+	n, err := t.l2.Incr(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return n, t.l1.Delete(ctx, key)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	if err := t.l2.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	return t.l1.Delete(ctx, keys...)
+}
+
+func (t *Tiered) Ping(ctx context.Context) error {
+	return t.l2.Ping(ctx)
+}
+
+func (t *Tiered) Close() {
+	t.l1.Close()
+	t.l2.Close()
+}
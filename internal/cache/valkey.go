@@ -2,9 +2,9 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ntentasd/nostradamus-api/pkg/types"
@@ -29,32 +29,78 @@ func NewValkey(addrs []string) *Valkey {
 	return &Valkey{client, cm}
 }
 
-func (v *Valkey) Store(prefix string, entry types.Entry) error {
+// zsetMember makes readings unique by timestamp, so equal values at different times
+// are not collapsed into one member. StoreMany keeps a single member per timestamp.
+func zsetMember(e types.Entry) string {
+	return strconv.FormatInt(e.Timestamp.UnixMilli(), 10) + ":" + strconv.FormatFloat(e.Value, 'g', -1, 64)
+}
+
+func (v *Valkey) Store(ctx context.Context, prefix string, entry types.Entry) error {
+	return v.StoreMany(ctx, prefix, []types.Entry{entry}, LatestTTL)
+}
+
+// StoreMany adds entries, trims the ZSET and refreshes its TTL in a single MULTI/EXEC.
+// Like the data tables and the Memcached ring, an entry replaces any with the same timestamp.
+func (v *Valkey) StoreMany(ctx context.Context, key string, entries []types.Entry, ttl time.Duration) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, span := otel.Tracer("nostradamus-cache").Start(ctx, "cache.StoreMany")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("cache.driver", "valkey"),
+		attribute.String("cache.key", key),
+		attribute.Int("cache.entries", len(entries)),
+	)
+
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
+		ctx,
 		time.Millisecond*200,
 	)
 	defer cancel()
 
-	_, err := v.client.ZAdd(ctx, prefix, redis.Z{
-		Score:  float64(entry.Timestamp.UnixMilli()),
-		Member: entry.Value,
-	}).Result()
-	if err != nil {
-		return err
+	// The last entry of a timestamp wins, as in insertRing
+	latest := make(map[int64]types.Entry, len(entries))
+	for _, e := range entries {
+		latest[e.Timestamp.UnixMilli()] = e
 	}
 
-	_, err = v.client.Expire(ctx, prefix, time.Hour).Result()
+	members := make([]redis.Z, 0, len(latest))
+	for ts, e := range latest {
+		members = append(members, redis.Z{
+			Score:  float64(ts),
+			Member: zsetMember(e),
+		})
+	}
+
+	start := time.Now()
+	_, err := v.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for ts := range latest {
+			score := strconv.FormatInt(ts, 10)
+			pipe.ZRemRangeByScore(ctx, key, score, score)
+		}
+		pipe.ZAdd(ctx, key, members...)
+		// Drop everything but the newest MaxLatestEntries
+		pipe.ZRemRangeByRank(ctx, key, 0, -MaxLatestEntries-1)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
 	if err != nil {
-		return err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to store entries: %w", err)
 	}
+	v.metrics.RecordWrite(start)
+	span.SetStatus(codes.Ok, "")
 
 	return nil
 }
 
-func (v *Valkey) FetchLast(prefix string, n int) ([]types.Entry, error) {
+func (v *Valkey) FetchLast(ctx context.Context, prefix string, n int) ([]types.Entry, error) {
 	ctx, cancel := context.WithTimeout(
-		context.Background(),
+		ctx,
 		time.Millisecond*100,
 	)
 	defer cancel()
@@ -75,6 +121,11 @@ func (v *Valkey) FetchLast(prefix string, n int) ([]types.Entry, error) {
 			return nil, fmt.Errorf("expected string, got %T", e.Member)
 		}
 
+		// Members written before zsetMember hold the bare value
+		if _, value, ok := strings.Cut(s, ":"); ok {
+			s = value
+		}
+
 		val, err := strconv.ParseFloat(s, 64)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse value: %w", err)
@@ -89,7 +140,7 @@ func (v *Valkey) FetchLast(prefix string, n int) ([]types.Entry, error) {
 	return ret, nil
 }
 
-func (v *Valkey) StoreAggregate(ctx context.Context, key string, data any, ttl time.Duration) error {
+func (v *Valkey) StoreAggregate(ctx context.Context, key string, data []byte, ttl time.Duration) error {
 	ctx, span := otel.Tracer("nostradamus-cache").Start(ctx, "cache.StoreAggregate")
 	defer span.End()
 
@@ -105,15 +156,8 @@ func (v *Valkey) StoreAggregate(ctx context.Context, key string, data any, ttl t
 	)
 	defer cancel()
 
-	b, err := json.Marshal(data)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		return fmt.Errorf("failed to marshal aggregate: %w", err)
-	}
-
 	start := time.Now()
-	if err := v.client.Set(ctx, key, b, ttl).Err(); err != nil {
+	if err := v.client.Set(ctx, key, data, ttl).Err(); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to store aggregate: %w", err)
@@ -146,7 +190,7 @@ func (v *Valkey) FetchAggregate(ctx context.Context, key string) ([]byte, error)
 		v.metrics.RecordMiss()
 		span.SetAttributes(attribute.String("cache.result", "miss"))
 		span.SetStatus(codes.Ok, "")
-		return nil, fmt.Errorf("cache miss")
+		return nil, ErrMiss
 	case err != nil:
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -159,6 +203,37 @@ func (v *Valkey) FetchAggregate(ctx context.Context, key string) ([]byte, error)
 	}
 }
 
+func (v *Valkey) Incr(ctx context.Context, key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Millisecond*200,
+	)
+	defer cancel()
+
+	return v.client.Incr(ctx, key).Result()
+}
+
+func (v *Valkey) Delete(ctx context.Context, keys ...string) error {
+	ctx, cancel := context.WithTimeout(
+		ctx,
+		time.Millisecond*200,
+	)
+	defer cancel()
+
+	// Keys may live in different slots, so delete them one by one
+	for _, key := range keys {
+		if err := v.client.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func (v *Valkey) setTier(tier string) {
+	v.metrics.tier = tier
+}
+
 func (v *Valkey) Ping(ctx context.Context) error {
 	return v.client.Ping(ctx).Err()
 }
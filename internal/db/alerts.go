@@ -0,0 +1,317 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+var ErrAlertRuleNotFound = errors.New("alert rule not found")
+
+const alertRuleColumns = `user_id, rule_id, field_id, sensor_id, sensor_type, comparator, threshold, hysteresis, for_ms, severity, enabled, created_at, updated_at`
+
+// alertRuleRow holds the scan targets of alertRuleColumns.
+type alertRuleRow struct {
+	userID, ruleID, fieldID gocql.UUID
+	sensorID                *gocql.UUID
+	sensorType              string
+	comparator              string
+	threshold, hysteresis   float64
+	forMs                   int64
+	severity                string
+	enabled                 bool
+	createdAt, updatedAt    time.Time
+}
+
+func (r *alertRuleRow) dest() []any {
+	return []any{
+		&r.userID, &r.ruleID, &r.fieldID, &r.sensorID, &r.sensorType, &r.comparator,
+		&r.threshold, &r.hysteresis, &r.forMs, &r.severity, &r.enabled, &r.createdAt, &r.updatedAt,
+	}
+}
+
+func (r *alertRuleRow) rule() (types.AlertRule, error) {
+	sType, err := parseSensorType(r.sensorType)
+	if err != nil {
+		return types.AlertRule{}, err
+	}
+
+	rule := types.AlertRule{
+		RuleID:     uuid.UUID(r.ruleID),
+		UserID:     uuid.UUID(r.userID),
+		FieldID:    uuid.UUID(r.fieldID),
+		SensorType: sType,
+		Comparator: types.AlertComparator(r.comparator),
+		Threshold:  r.threshold,
+		Hysteresis: r.hysteresis,
+		For:        types.Duration(time.Duration(r.forMs) * time.Millisecond),
+		Severity:   types.AlertSeverity(r.severity),
+		Enabled:    r.enabled,
+		CreatedAt:  r.createdAt,
+		UpdatedAt:  r.updatedAt,
+	}
+	if r.sensorID != nil {
+		sensorID := uuid.UUID(*r.sensorID)
+		rule.SensorID = &sensorID
+	}
+
+	return rule, nil
+}
+
+func (db *DB) writeAlertRule(ctx context.Context, rule types.AlertRule) error {
+	var sensorID *gocql.UUID
+	if rule.SensorID != nil {
+		id := gocql.UUID(*rule.SensorID)
+		sensorID = &id
+	}
+
+	return db.Meta.Query(`
+INSERT INTO alert_rules (`+alertRuleColumns+`)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`,
+		gocql.UUID(rule.UserID),
+		gocql.UUID(rule.RuleID),
+		gocql.UUID(rule.FieldID),
+		sensorID,
+		strconv.Itoa(int(rule.SensorType)),
+		string(rule.Comparator),
+		rule.Threshold,
+		rule.Hysteresis,
+		time.Duration(rule.For).Milliseconds(),
+		string(rule.Severity),
+		rule.Enabled,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	).WithContext(ctx).Exec()
+}
+
+// CreateAlertRule stores a new rule and returns it with its ID and timestamps set.
+func (db *DB) CreateAlertRule(rule types.AlertRule) (*types.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	rule.RuleID = uuid.New()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	if err := db.writeAlertRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// UpdateAlertRule overwrites a rule and resets its alert state, so changed conditions
+// are evaluated from scratch.
+func (db *DB) UpdateAlertRule(rule types.AlertRule) (*types.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	rule.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+
+	if err := db.writeAlertRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := db.Meta.Query(`DELETE FROM alert_state WHERE rule_id = ?`, gocql.UUID(rule.RuleID)).WithContext(ctx).Exec(); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (db *DB) GetAlertRule(userID, ruleID uuid.UUID) (*types.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var row alertRuleRow
+	err := db.Meta.Query(`
+SELECT `+alertRuleColumns+`
+FROM alert_rules
+WHERE user_id = ? AND rule_id = ?
+`, gocql.UUID(userID), gocql.UUID(ruleID)).WithContext(ctx).Scan(row.dest()...)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, ErrAlertRuleNotFound
+		}
+		return nil, err
+	}
+
+	rule, err := row.rule()
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListAlertRules returns the rules of a user.
+func (db *DB) ListAlertRules(userID uuid.UUID) ([]types.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	return db.scanAlertRules(db.Meta.Query(`
+SELECT `+alertRuleColumns+`
+FROM alert_rules
+WHERE user_id = ?
+`, gocql.UUID(userID)).WithContext(ctx).Iter())
+}
+
+// ListAllAlertRules scans alert_rules for the rules of every user.
+func (db *DB) ListAllAlertRules(ctx context.Context) ([]types.AlertRule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return db.scanAlertRules(db.Meta.Query(`
+SELECT ` + alertRuleColumns + `
+FROM alert_rules
+`).WithContext(ctx).PageSize(1000).Iter())
+}
+
+func (db *DB) scanAlertRules(iter *gocql.Iter) ([]types.AlertRule, error) {
+	rules := []types.AlertRule{}
+
+	var row alertRuleRow
+	for iter.Scan(row.dest()...) {
+		rule, err := row.rule()
+		if err != nil {
+			db.logger.Warn().Err(err).Str("rule_id", row.ruleID.String()).Msg("skipping invalid alert rule")
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// DeleteAlertRule removes a rule and its alert state. Its history is kept.
+func (db *DB) DeleteAlertRule(userID, ruleID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`DELETE FROM alert_rules WHERE user_id = ? AND rule_id = ?`, gocql.UUID(userID), gocql.UUID(ruleID))
+	batch.Query(`DELETE FROM alert_state WHERE rule_id = ?`, gocql.UUID(ruleID))
+
+	return batch.Exec()
+}
+
+// FiringAlert is a rule currently firing for a sensor.
+type FiringAlert struct {
+	RuleID   uuid.UUID
+	SensorID uuid.UUID
+	Since    time.Time
+}
+
+// ListFiringAlerts scans alert_state for every rule and sensor currently firing.
+func (db *DB) ListFiringAlerts(ctx context.Context) ([]FiringAlert, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT rule_id, sensor_id, state, changed_at
+FROM alert_state
+`).WithContext(ctx).PageSize(1000).Iter()
+
+	var (
+		alerts    []FiringAlert
+		ruleID    gocql.UUID
+		sensorID  gocql.UUID
+		state     string
+		changedAt time.Time
+	)
+	for iter.Scan(&ruleID, &sensorID, &state, &changedAt) {
+		if types.AlertState(state) != types.AlertStateFiring {
+			continue
+		}
+		alerts = append(alerts, FiringAlert{
+			RuleID:   uuid.UUID(ruleID),
+			SensorID: uuid.UUID(sensorID),
+			Since:    changedAt,
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+// RecordAlertEvent stores the new state of a rule for a sensor and appends the
+// transition to its history.
+func (db *DB) RecordAlertEvent(ctx context.Context, event types.AlertEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+INSERT INTO alert_state (rule_id, sensor_id, state, changed_at)
+VALUES (?, ?, ?, ?)
+`, gocql.UUID(event.RuleID), gocql.UUID(event.SensorID), string(event.State), event.Timestamp)
+	batch.Query(`
+INSERT INTO alert_history (rule_id, event_id, sensor_id, field_id, state, severity, comparator, threshold, value)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`,
+		gocql.UUID(event.RuleID),
+		gocql.UUID(event.EventID),
+		gocql.UUID(event.SensorID),
+		gocql.UUID(event.FieldID),
+		string(event.State),
+		string(event.Severity),
+		string(event.Comparator),
+		event.Threshold,
+		event.Value,
+	)
+
+	return batch.Exec()
+}
+
+// GetAlertHistory returns the latest transitions of a rule, newest first.
+func (db *DB) GetAlertHistory(ruleID uuid.UUID, limit int) ([]types.AlertEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT event_id, sensor_id, field_id, state, severity, comparator, threshold, value
+FROM alert_history
+WHERE rule_id = ?
+LIMIT ?
+`, gocql.UUID(ruleID), limit).WithContext(ctx).Iter()
+
+	var (
+		events                      []types.AlertEvent
+		eventID, sensorID, fieldID  gocql.UUID
+		state, severity, comparator string
+		threshold, value            float64
+	)
+	for iter.Scan(&eventID, &sensorID, &fieldID, &state, &severity, &comparator, &threshold, &value) {
+		events = append(events, types.AlertEvent{
+			EventID:    uuid.UUID(eventID),
+			RuleID:     ruleID,
+			SensorID:   uuid.UUID(sensorID),
+			FieldID:    uuid.UUID(fieldID),
+			State:      types.AlertState(state),
+			Severity:   types.AlertSeverity(severity),
+			Comparator: types.AlertComparator(comparator),
+			Threshold:  threshold,
+			Value:      value,
+			Timestamp:  eventID.Time().UTC(),
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+)
+
+type CredentialAction string
+
+const (
+	CredentialActionRotate CredentialAction = "rotate"
+	CredentialActionRevoke CredentialAction = "revoke"
+)
+
+// CredentialAuditEntry records who changed a sensor's MQTT credentials and when.
+type CredentialAuditEntry struct {
+	SensorID   uuid.UUID        `json:"sensor_id"`
+	Action     CredentialAction `json:"action"`
+	ActorID    uuid.UUID        `json:"actor_id"`
+	AuthMethod string           `json:"auth_method"`
+	MqttUser   string           `json:"mqtt_username"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+func (db *DB) RecordCredentialAudit(entry CredentialAuditEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	return db.Meta.Query(`
+INSERT INTO credential_audit (sensor_id, event_id, action, actor_id, auth_method, mqtt_username)
+VALUES (?, ?, ?, ?, ?, ?)
+`,
+		gocql.UUID(entry.SensorID),
+		gocql.UUIDFromTime(entry.Timestamp),
+		string(entry.Action),
+		gocql.UUID(entry.ActorID),
+		entry.AuthMethod,
+		entry.MqttUser,
+	).WithContext(ctx).Exec()
+}
@@ -3,19 +3,23 @@ package db
 import (
 	"github.com/gocql/gocql"
 	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/internal/secrets"
 )
 
 type DB struct {
-	Meta   *gocql.Session // sensors_data
-	Data   *gocql.Session // sensors_data
-	logger zerolog.Logger
+	Meta    *gocql.Session // sensors_data
+	Data    *gocql.Session // sensors_data
+	keyring *secrets.Keyring
+	logger  zerolog.Logger
 }
 
-func New(metaSess, dataSess *gocql.Session, logger zerolog.Logger) *DB {
+func New(metaSess, dataSess *gocql.Session, keyring *secrets.Keyring, logger zerolog.Logger) *DB {
 	return &DB{
-		Meta:   metaSess,
-		Data:   dataSess,
-		logger: logger,
+		Meta:    metaSess,
+		Data:    dataSess,
+		keyring: keyring,
+		logger:  logger,
 	}
 }
 
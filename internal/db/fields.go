@@ -127,3 +127,144 @@ VALUES (?, ?, ?)
 		FieldName: fieldName,
 	}, nil
 }
+
+// RenameField renames a field and keeps the field_name static column of sensors_by_field in sync.
+func (db *DB) RenameField(userID, fieldID uuid.UUID, fieldName string) (*types.Field, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT field_id, field_name FROM fields
+WHERE user_id = ?
+`, gocql.UUID(userID)).WithContext(ctx).Iter()
+
+	var (
+		existingID   gocql.UUID
+		existingName string
+		found        bool
+	)
+	for iter.Scan(&existingID, &existingName) {
+		if uuid.UUID(existingID) == fieldID {
+			found = true
+			continue
+		}
+		if existingName == fieldName {
+			iter.Close()
+			return nil, &FieldAlreadyExistsError{
+				FieldName: fieldName,
+			}
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrFieldNotFound
+	}
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+UPDATE fields SET field_name = ?
+WHERE user_id = ? AND field_id = ?
+`, fieldName, gocql.UUID(userID), gocql.UUID(fieldID))
+	batch.Query(`
+UPDATE sensors_by_field SET field_name = ?
+WHERE field_id = ?
+`, fieldName, gocql.UUID(fieldID))
+
+	if err := batch.Exec(); err != nil {
+		return nil, err
+	}
+
+	return &types.Field{
+		UserID:    &userID,
+		FieldID:   fieldID,
+		FieldName: fieldName,
+	}, nil
+}
+
+// DeletedSensor describes a sensor removed along with its field, for cleanup outside Scylla.
+type DeletedSensor struct {
+	SensorID   uuid.UUID
+	SensorType types.SensorType
+	MqttUser   string
+	// RegisteredAt is zero for sensors registered before it was recorded
+	RegisteredAt time.Time
+}
+
+// DeleteField removes a field, its sensors_by_field partition and the sensors' rows in sensors
+// in a single logged batch, and returns the removed sensors.
+func (db *DB) DeleteField(userID, fieldID uuid.UUID) ([]DeletedSensor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT sensor_id, sensor_type, mqtt_username
+FROM sensors_by_field
+WHERE field_id = ?
+`, gocql.UUID(fieldID)).WithContext(ctx).Iter()
+
+	var (
+		deleted    []DeletedSensor
+		sensorID   gocql.UUID
+		sensorType string
+		mqttUser   string
+	)
+	for iter.Scan(&sensorID, &sensorType, &mqttUser) {
+		// A static-only row has no sensor
+		if sensorID == (gocql.UUID{}) {
+			continue
+		}
+
+		sType, err := parseSensorType(sensorType)
+		if err != nil {
+			db.logger.Warn().Err(err).Str("sensor_id", sensorID.String()).Str("sensor_type", sensorType).Msg("invalid sensor type")
+			sType = -1
+		}
+
+		deleted = append(deleted, DeletedSensor{
+			SensorID:   uuid.UUID(sensorID),
+			SensorType: sType,
+			MqttUser:   mqttUser,
+		})
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	// Purging the sensors' data later needs to know how far back it goes
+	for i := range deleted {
+		var registeredAt time.Time
+		err := db.Meta.Query(`
+SELECT registered_at FROM sensors
+WHERE sensor_id = ?
+`, gocql.UUID(deleted[i].SensorID)).WithContext(ctx).Scan(&registeredAt)
+		if err != nil && err != gocql.ErrNotFound {
+			return nil, err
+		}
+		deleted[i].RegisteredAt = registeredAt
+	}
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+DELETE FROM fields
+WHERE user_id = ? AND field_id = ?
+`, gocql.UUID(userID), gocql.UUID(fieldID))
+	batch.Query(`
+DELETE FROM sensors_by_field
+WHERE field_id = ?
+`, gocql.UUID(fieldID))
+	for _, s := range deleted {
+		batch.Query(`
+DELETE FROM sensors
+WHERE sensor_id = ?
+`, gocql.UUID(s.SensorID))
+	}
+
+	if err := batch.Exec(); err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
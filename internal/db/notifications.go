@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+var ErrChannelNotFound = errors.New("notification channel not found")
+
+const channelColumns = `user_id, channel_id, name, type, url, secret, secret_key_id, field_id, max_attempts, backoff_ms, enabled, created_at`
+
+// channelRow holds the scan targets of channelColumns.
+type channelRow struct {
+	userID, channelID   gocql.UUID
+	name, chType, url   string
+	secret, secretKeyID string
+	fieldID             *gocql.UUID
+	maxAttempts         int
+	backoffMs           int64
+	enabled             bool
+	createdAt           time.Time
+}
+
+func (r *channelRow) dest() []any {
+	return []any{
+		&r.userID, &r.channelID, &r.name, &r.chType, &r.url, &r.secret, &r.secretKeyID,
+		&r.fieldID, &r.maxAttempts, &r.backoffMs, &r.enabled, &r.createdAt,
+	}
+}
+
+// channel decrypts the row into a channel, secret included.
+func (db *DB) channel(r *channelRow) (types.NotificationChannel, error) {
+	ch := types.NotificationChannel{
+		ChannelID:   uuid.UUID(r.channelID),
+		UserID:      uuid.UUID(r.userID),
+		Name:        r.name,
+		Type:        types.NotificationChannelType(r.chType),
+		URL:         r.url,
+		MaxAttempts: r.maxAttempts,
+		Backoff:     types.Duration(time.Duration(r.backoffMs) * time.Millisecond),
+		Enabled:     r.enabled,
+		CreatedAt:   r.createdAt,
+	}
+	if r.fieldID != nil {
+		fieldID := uuid.UUID(*r.fieldID)
+		ch.FieldID = &fieldID
+	}
+
+	if r.secret != "" {
+		b, err := db.keyring.Open(r.secretKeyID, r.secret, r.channelID.Bytes())
+		if err != nil {
+			return types.NotificationChannel{}, fmt.Errorf("failed to decrypt channel secret: %w", err)
+		}
+		ch.Secret = string(b)
+	}
+
+	return ch, nil
+}
+
+// CreateNotificationChannel stores a new channel, encrypting its secret with the primary
+// key, and returns it with its ID and creation time set.
+func (db *DB) CreateNotificationChannel(ch types.NotificationChannel) (*types.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	ch.ChannelID = uuid.New()
+	ch.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+
+	var keyID, sealed string
+	if ch.Secret != "" {
+		var err error
+		keyID, sealed, err = db.keyring.Seal([]byte(ch.Secret), ch.ChannelID[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt channel secret: %w", err)
+		}
+	}
+
+	var fieldID *gocql.UUID
+	if ch.FieldID != nil {
+		id := gocql.UUID(*ch.FieldID)
+		fieldID = &id
+	}
+
+	err := db.Meta.Query(`
+INSERT INTO notification_channels (`+channelColumns+`)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`,
+		gocql.UUID(ch.UserID),
+		gocql.UUID(ch.ChannelID),
+		ch.Name,
+		string(ch.Type),
+		ch.URL,
+		sealed,
+		keyID,
+		fieldID,
+		ch.MaxAttempts,
+		time.Duration(ch.Backoff).Milliseconds(),
+		ch.Enabled,
+		ch.CreatedAt,
+	).WithContext(ctx).Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ch, nil
+}
+
+// GetNotificationChannel returns a channel of a user, secret included.
+func (db *DB) GetNotificationChannel(userID, channelID uuid.UUID) (*types.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var row channelRow
+	err := db.Meta.Query(`
+SELECT `+channelColumns+`
+FROM notification_channels
+WHERE user_id = ? AND channel_id = ?
+`, gocql.UUID(userID), gocql.UUID(channelID)).WithContext(ctx).Scan(row.dest()...)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+
+	ch, err := db.channel(&row)
+	if err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// ListNotificationChannels returns the channels of a user, secrets included.
+func (db *DB) ListNotificationChannels(ctx context.Context, userID uuid.UUID) ([]types.NotificationChannel, error) {
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT `+channelColumns+`
+FROM notification_channels
+WHERE user_id = ?
+`, gocql.UUID(userID)).WithContext(ctx).Iter()
+
+	channels := []types.NotificationChannel{}
+
+	var row channelRow
+	for iter.Scan(row.dest()...) {
+		ch, err := db.channel(&row)
+		if err != nil {
+			db.logger.Warn().Err(err).Str("channel_id", row.channelID.String()).Msg("skipping unreadable notification channel")
+			continue
+		}
+		channels = append(channels, ch)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return channels, nil
+}
+
+// DeleteNotificationChannel removes a channel. Its dead letters are kept.
+func (db *DB) DeleteNotificationChannel(userID, channelID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	return db.Meta.Query(`
+DELETE FROM notification_channels
+WHERE user_id = ? AND channel_id = ?
+`, gocql.UUID(userID), gocql.UUID(channelID)).WithContext(ctx).Exec()
+}
+
+// RecordDeadLetter stores a notification that exhausted its delivery attempts.
+func (db *DB) RecordDeadLetter(ctx context.Context, letter types.DeadLetter) error {
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	return db.Meta.Query(`
+INSERT INTO notification_dead_letters (channel_id, letter_id, rule_id, event_id, payload, error, attempts)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`,
+		gocql.UUID(letter.ChannelID),
+		gocql.UUIDFromTime(letter.Timestamp),
+		gocql.UUID(letter.RuleID),
+		gocql.UUID(letter.EventID),
+		letter.Payload,
+		letter.Error,
+		letter.Attempts,
+	).WithContext(ctx).Exec()
+}
+
+// GetDeadLetters returns the latest failed deliveries of a channel, newest first.
+func (db *DB) GetDeadLetters(channelID uuid.UUID, limit int) ([]types.DeadLetter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT letter_id, rule_id, event_id, payload, error, attempts
+FROM notification_dead_letters
+WHERE channel_id = ?
+LIMIT ?
+`, gocql.UUID(channelID), limit).WithContext(ctx).Iter()
+
+	var (
+		letters                   []types.DeadLetter
+		letterID, ruleID, eventID gocql.UUID
+		payload, lastErr          string
+		attempts                  int
+	)
+	for iter.Scan(&letterID, &ruleID, &eventID, &payload, &lastErr, &attempts) {
+		letters = append(letters, types.DeadLetter{
+			LetterID:  uuid.UUID(letterID),
+			ChannelID: channelID,
+			RuleID:    uuid.UUID(ruleID),
+			EventID:   uuid.UUID(eventID),
+			Payload:   payload,
+			Error:     lastErr,
+			Attempts:  attempts,
+			Timestamp: letterID.Time().UTC(),
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return letters, nil
+}
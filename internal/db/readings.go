@@ -2,10 +2,16 @@ package db
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/google/uuid"
 	"github.com/ntentasd/nostradamus-api/internal/metrics"
 	"github.com/ntentasd/nostradamus-api/pkg/types"
 	"go.opentelemetry.io/otel"
@@ -13,9 +19,28 @@ import (
 	"go.opentelemetry.io/otel/codes"
 )
 
-// GetReadings returns all sensor readings between two timestamps, possibly spanning multiple bucket_dates.
-func (db *DB) GetReadings(ctx context.Context, sensorID string, sType int, from, to time.Time) ([]float64, error) {
-	ctx, span := otel.Tracer("nostradamus-db").Start(ctx, "db.GetReadings")
+// maxLookbackDays bounds how many bucket_date partitions GetLastValues walks back through.
+const maxLookbackDays = 30
+
+// sensorTable maps a sensor type to its table in the sensors_data keyspace.
+func sensorTable(sType int) (string, error) {
+	switch sType {
+	case int(types.SensorTypeTemperature):
+		return "temperatures", nil
+	case int(types.SensorTypeHumidity):
+		return "humidities", nil
+	case int(types.SensorTypePHLevel):
+		return "ph_levels", nil
+	default:
+		return "", types.ErrInvalidSensorType
+	}
+}
+
+// GetLastValues returns the n most recent readings of a sensor, newest first.
+// It starts at today's bucket_date and walks back one day at a time until n rows are found
+// or maxLookbackDays buckets have been read.
+func (db *DB) GetLastValues(ctx context.Context, sensorID string, sType int, n int) ([]types.Entry, error) {
+	ctx, span := otel.Tracer("nostradamus-db").Start(ctx, "db.GetLastValues")
 	defer span.End()
 
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
@@ -28,23 +53,107 @@ func (db *DB) GetReadings(ctx context.Context, sensorID string, sType int, from,
 		return nil, fmt.Errorf("invalid sensor_id: %w", err)
 	}
 
-	var sensorType string
-	switch sType {
-	case int(types.SensorTypeTemperature):
-		sensorType = "temperatures"
-	case int(types.SensorTypeHumidity):
-		sensorType = "humidities"
-	case int(types.SensorTypePHLevel):
-		sensorType = "ph_levels"
-	default:
-		err := fmt.Errorf("invalid sensor type")
+	table, err := sensorTable(sType)
+	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
+	query := fmt.Sprintf(`
+SELECT timestamp, value
+FROM sensors_data.%s
+WHERE sensor_id = ? AND bucket_date = ?
+ORDER BY timestamp DESC
+LIMIT ?
+`, table)
+
+	results := make([]types.Entry, 0, n)
+
+	year, month, day := time.Now().UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxLookbackDays && len(results) < n; i++ {
+		bucket := today.AddDate(0, 0, -i).Format("2006-01-02")
+
+		start := time.Now()
+		iter := db.Data.Query(query, sid, bucket, n-len(results)).WithContext(ctx).Iter()
+
+		var (
+			ts  time.Time
+			val float64
+		)
+		for iter.Scan(&ts, &val) {
+			val, _ = strconv.ParseFloat(fmt.Sprintf("%.4f", val), 64)
+			results = append(results, types.Entry{
+				Timestamp: ts,
+				Value:     val,
+			})
+		}
+
+		if err := iter.Close(); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("failed to query bucket %s: %w", bucket, err)
+		}
+
+		metrics.DbReadLatencySeconds.WithLabelValues("latest").Observe(time.Since(start).Seconds())
+	}
+
+	span.SetAttributes(attribute.Int("readings.count", len(results)))
+	return results, nil
+}
+
+// GetReadings returns all sensor readings between two timestamps, possibly spanning multiple bucket_dates.
+func (db *DB) GetReadings(ctx context.Context, sensorID string, sType int, from, to time.Time) ([]float64, error) {
+	ctx, span := otel.Tracer("nostradamus-db").Start(ctx, "db.GetReadings")
+	defer span.End()
+
 	readings := make([]float64, 0, 256)
 
+	err := db.ScanReadings(ctx, sensorID, sType, from, to, func(e types.Entry) error {
+		readings = append(readings, e.Value)
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+// ScanReadings streams the sensor readings between two timestamps to fn in ascending timestamp order,
+// without holding the whole range in memory. A non-nil error from fn stops the scan and is returned as is.
+func (db *DB) ScanReadings(ctx context.Context, sensorID string, sType int, from, to time.Time, fn func(types.Entry) error) error {
+	ctx, span := otel.Tracer("nostradamus-db").Start(ctx, "db.ScanReadings")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	sid, err := gocql.ParseUUID(sensorID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("invalid sensor_id: %w", err)
+	}
+
+	sensorType, err := sensorTable(sType)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	query := fmt.Sprintf(`
+SELECT timestamp, value
+FROM sensors_data.%s
+WHERE sensor_id = ? AND bucket_date = ? AND timestamp >= ? AND timestamp <= ?
+ORDER BY timestamp ASC
+`, sensorType)
+
 	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
 	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
 
@@ -52,13 +161,6 @@ func (db *DB) GetReadings(ctx context.Context, sensorID string, sType int, from,
 	for date := start; !date.After(end); date = date.Add(24 * time.Hour) {
 		bucket := date.Format("2006-01-02")
 
-		query := fmt.Sprintf(`
-SELECT value
-FROM sensors_data.%s
-WHERE sensor_id = ? AND bucket_date = ? AND timestamp >= ? AND timestamp <= ?
-ORDER BY timestamp DESC
-`, sensorType)
-
 		qctx, qspan := otel.Tracer("nostradamus-db").Start(ctx, "db.query")
 		qspan.SetAttributes(
 			attribute.String("table", sensorType),
@@ -69,21 +171,281 @@ ORDER BY timestamp DESC
 		start := time.Now()
 		iter := db.Data.Query(query, sid, bucket, from, to).WithContext(qctx).Iter()
 
-		var v float64
-		for iter.Scan(&v) {
-			readings = append(readings, v)
+		var (
+			ts      time.Time
+			v       float64
+			scanErr error
+		)
+		for iter.Scan(&ts, &v) {
+			if scanErr = fn(types.Entry{Timestamp: ts, Value: v}); scanErr != nil {
+				break
+			}
 		}
 
 		if err := iter.Close(); err != nil {
 			qspan.RecordError(err)
 			qspan.SetStatus(codes.Error, err.Error())
 			qspan.End()
-			return nil, fmt.Errorf("failed to query bucket %s: %w", bucket, err)
+			return fmt.Errorf("failed to query bucket %s: %w", bucket, err)
 		}
 
 		metrics.DbReadLatencySeconds.WithLabelValues("readings").Observe(time.Since(start).Seconds())
 		qspan.End()
+
+		if scanErr != nil {
+			return scanErr
+		}
 	}
 
-	return readings, nil
+	return nil
+}
+
+// ReadingsCursor marks where a paged readings query stopped: the bucket_date being read
+// and the driver page state inside it. It is only valid for the query that produced it.
+type ReadingsCursor struct {
+	Bucket    string `json:"b"`
+	PageState []byte `json:"p,omitempty"`
+}
+
+// Encode serializes the cursor into an opaque URL-safe token.
+func (c *ReadingsCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeReadingsCursor parses a token produced by ReadingsCursor.Encode.
+func DecodeReadingsCursor(token string) (*ReadingsCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c ReadingsCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if _, err := time.Parse("2006-01-02", c.Bucket); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ReadingsPage is one page of raw readings; Next is nil once the range is exhausted.
+type ReadingsPage struct {
+	Entries []types.Entry
+	Next    *ReadingsCursor
+}
+
+// GetReadingsPage returns up to limit readings between two timestamps, resuming from cursor when set.
+// Pages span bucket_date partitions in the requested order.
+func (db *DB) GetReadingsPage(
+	ctx context.Context,
+	sensorID string,
+	sType int,
+	from, to time.Time,
+	limit int,
+	ascending bool,
+	cursor *ReadingsCursor,
+) (*ReadingsPage, error) {
+	ctx, span := otel.Tracer("nostradamus-db").Start(ctx, "db.GetReadingsPage")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	sid, err := gocql.ParseUUID(sensorID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("invalid sensor_id: %w", err)
+	}
+
+	table, err := sensorTable(sType)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	order := "DESC"
+	if ascending {
+		order = "ASC"
+	}
+
+	query := fmt.Sprintf(`
+SELECT timestamp, value
+FROM sensors_data.%s
+WHERE sensor_id = ? AND bucket_date = ? AND timestamp >= ? AND timestamp <= ?
+ORDER BY timestamp %s
+`, table, order)
+
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	var buckets []string
+	for date := first; !date.After(last); date = date.Add(24 * time.Hour) {
+		buckets = append(buckets, date.Format("2006-01-02"))
+	}
+	if !ascending {
+		slices.Reverse(buckets)
+	}
+
+	idx := 0
+	var pageState []byte
+	if cursor != nil {
+		idx = slices.Index(buckets, cursor.Bucket)
+		if idx < 0 {
+			return nil, ErrInvalidCursor
+		}
+		pageState = cursor.PageState
+	}
+
+	page := &ReadingsPage{
+		Entries: make([]types.Entry, 0, limit),
+	}
+
+	for idx < len(buckets) {
+		bucket := buckets[idx]
+
+		start := time.Now()
+		iter := db.Data.Query(query, sid, bucket, from, to).
+			WithContext(ctx).
+			PageSize(limit - len(page.Entries)).
+			PageState(pageState).
+			Iter()
+		next := iter.PageState()
+
+		var (
+			ts  time.Time
+			val float64
+		)
+		for iter.Scan(&ts, &val) {
+			page.Entries = append(page.Entries, types.Entry{
+				Timestamp: ts,
+				Value:     val,
+			})
+		}
+
+		if err := iter.Close(); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, fmt.Errorf("failed to query bucket %s: %w", bucket, err)
+		}
+		metrics.DbReadLatencySeconds.WithLabelValues("readings_page").Observe(time.Since(start).Seconds())
+
+		if len(next) > 0 {
+			pageState = next
+		} else {
+			pageState = nil
+			idx++
+		}
+
+		if len(page.Entries) >= limit {
+			break
+		}
+	}
+
+	if idx < len(buckets) {
+		page.Next = &ReadingsCursor{
+			Bucket:    buckets[idx],
+			PageState: pageState,
+		}
+	}
+
+	span.SetAttributes(attribute.Int("readings.count", len(page.Entries)))
+	return page, nil
+}
+
+// legacyPurgeDays is how far back PurgeSensorData deletes bucket_date partitions of
+// sensors registered before registered_at was recorded. Older data of those sensors is
+// left behind.
+const legacyPurgeDays = 365
+
+// purgeSlack covers readings timestamped shortly before their sensor was registered,
+// like buffered readings of a device re-registered under a new ID.
+const purgeSlack = 24 * time.Hour
+
+// sensorBuckets returns the bucket_date partitions a sensor may have written to, from
+// today back to its registration, in chunks small enough for an IN list. A zero
+// registeredAt covers the last legacyPurgeDays days only.
+func sensorBuckets(registeredAt time.Time) [][]string {
+	year, month, day := time.Now().UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	days := legacyPurgeDays
+	if !registeredAt.IsZero() {
+		days = max(int(today.Sub(registeredAt.UTC().Add(-purgeSlack)).Hours()/24)+1, 0)
+	}
+
+	// Keep each IN list reasonably small
+	const chunk = 50
+	var chunks [][]string
+	for offset := 0; offset <= days; offset += chunk {
+		buckets := make([]string, 0, chunk)
+		for i := offset; i < offset+chunk && i <= days; i++ {
+			buckets = append(buckets, today.AddDate(0, 0, -i).Format("2006-01-02"))
+		}
+		chunks = append(chunks, buckets)
+	}
+	return chunks
+}
+
+// PurgeSensorData deletes every sensors_data partition of a sensor from today back to its
+// registration. A zero registeredAt purges the last legacyPurgeDays days only.
+func (db *DB) PurgeSensorData(ctx context.Context, sensorID uuid.UUID, sType int, registeredAt time.Time) error {
+	table, err := sensorTable(sType)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+DELETE FROM sensors_data.%s
+WHERE sensor_id = ? AND bucket_date IN ?
+`, table)
+
+	for _, buckets := range sensorBuckets(registeredAt) {
+		qctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := db.Data.Query(query, gocql.UUID(sensorID), buckets).WithContext(qctx).Exec()
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to purge %s partitions: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// hasReadings reports whether a sensor stored any reading of type sType since its
+// registration.
+func (db *DB) hasReadings(ctx context.Context, sensorID uuid.UUID, sType int, registeredAt time.Time) (bool, error) {
+	table, err := sensorTable(sType)
+	if err != nil {
+		return false, err
+	}
+
+	query := fmt.Sprintf(`
+SELECT timestamp
+FROM sensors_data.%s
+WHERE sensor_id = ? AND bucket_date IN ?
+LIMIT 1
+`, table)
+
+	for _, buckets := range sensorBuckets(registeredAt) {
+		qctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		var ts time.Time
+		err := db.Data.Query(query, gocql.UUID(sensorID), buckets).WithContext(qctx).Scan(&ts)
+		cancel()
+		if err == nil {
+			return true, nil
+		}
+		if err != gocql.ErrNotFound {
+			return false, fmt.Errorf("failed to query %s partitions: %w", table, err)
+		}
+	}
+
+	return false, nil
 }
@@ -20,48 +20,33 @@ func (e *SensorAlreadyExistsError) Error() string {
 	return fmt.Sprintf("sensor '%s' already exists", e.SensorName)
 }
 
-func (db *DB) GetLast5Values(
-	sensor string,
-	date string,
-) ([]types.Entry, error) {
-	ctx, cancel := context.WithTimeout(
-		context.Background(),
-		time.Millisecond*500,
-	)
-	defer cancel()
-
-	id, err := gocql.ParseUUID(sensor)
-	if err != nil {
-		return nil, err
+// parseSensorType accepts both the numeric form written by RegisterSensor and the named form.
+func parseSensorType(sensorType string) (types.SensorType, error) {
+	if n, err := strconv.Atoi(sensorType); err == nil {
+		return types.SensorType(n), nil
 	}
+	return types.ToSensorType(sensorType)
+}
 
-	query := db.Data.Query(`
-SELECT timestamp, value
-FROM temperatures
-WHERE sensor_id=?
-AND bucket_date=?
-ORDER BY timestamp DESC LIMIT 5
-`, id, date).WithContext(ctx)
-
-	var results []types.Entry
-	iter := query.Iter()
-
-	var ts time.Time
-	var val float64
-
-	for iter.Scan(&ts, &val) {
-		val, _ = strconv.ParseFloat(fmt.Sprintf("%.4f", val), 64)
-		results = append(results, types.Entry{
-			Timestamp: ts,
-			Value:     val,
-		})
-	}
+// GetSensorType resolves the type of a sensor from sensors_meta.sensors.
+func (db *DB) GetSensorType(ctx context.Context, sensorID uuid.UUID) (types.SensorType, error) {
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
 
-	if err := iter.Close(); err != nil {
-		return nil, err
+	var sensorType string
+	err := db.Meta.Query(`
+SELECT sensor_type
+FROM sensors
+WHERE sensor_id = ?
+`, gocql.UUID(sensorID)).WithContext(ctx).Scan(&sensorType)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return -1, ErrSensorNotFound
+		}
+		return -1, err
 	}
 
-	return results, nil
+	return parseSensorType(sensorType)
 }
 
 func (db *DB) GetSensorsByFieldID(fieldID uuid.UUID) ([]types.Sensor, *types.Field, error) {
@@ -69,7 +54,7 @@ func (db *DB) GetSensorsByFieldID(fieldID uuid.UUID) ([]types.Sensor, *types.Fie
 	defer cancel()
 
 	query := db.Meta.Query(`
-SELECT sensor_id, sensor_name, sensor_type, field_name
+SELECT sensor_id, sensor_name, sensor_type, field_name, decommissioned_at
 FROM sensors_by_field
 WHERE field_id = ?
 `, gocql.UUID(fieldID)).WithContext(ctx)
@@ -83,27 +68,26 @@ WHERE field_id = ?
 		sensorName string
 		sensorType string
 		fieldName  string
+		retiredAt  time.Time
 	)
 
-	for iter.Scan(&sensorID, &sensorName, &sensorType, &fieldName) {
-		var sType types.SensorType
-		sensorTypeInt, convErr := strconv.Atoi(sensorType)
-		if convErr == nil {
-			sType = types.SensorType(sensorTypeInt)
-		} else {
-			var err error
-			sType, err = types.ToSensorType(sensorType)
-			if err != nil {
-				db.logger.Warn().Err(err).Str("sensor_name", sensorName).Str("sensor_type", sensorType).Msg("invalid sensor type")
-				continue
-			}
+	for iter.Scan(&sensorID, &sensorName, &sensorType, &fieldName, &retiredAt) {
+		sType, err := parseSensorType(sensorType)
+		if err != nil {
+			db.logger.Warn().Err(err).Str("sensor_name", sensorName).Str("sensor_type", sensorType).Msg("invalid sensor type")
+			continue
 		}
 
-		results = append(results, types.Sensor{
+		sensor := types.Sensor{
 			SensorID:   sensorID,
 			SensorName: sensorName,
 			SensorType: sType,
-		})
+		}
+		if !retiredAt.IsZero() {
+			decommissionedAt := retiredAt
+			sensor.DecommissionedAt = &decommissionedAt
+		}
+		results = append(results, sensor)
 
 		if field == nil {
 			field = &types.Field{
@@ -156,18 +140,18 @@ WHERE field_id = ? AND sensor_id = ?
 	}
 
 	newID := uuid.New()
-	query := db.Meta.Query(`
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
 INSERT INTO sensors_by_field (field_id, sensor_id, sensor_name, sensor_type)
 VALUES (?, ?, ?, ?)
-`, gocql.UUID(fieldID), gocql.UUID(newID), sensorName, fmt.Sprintf("%d", sensorType)).WithContext(ctx)
-	if err := query.Exec(); err != nil {
-		return nil, err
-	}
+`, gocql.UUID(fieldID), gocql.UUID(newID), sensorName, fmt.Sprintf("%d", sensorType))
+	batch.Query(`
+INSERT INTO sensors (sensor_id, field_id, sensor_name, sensor_type, registered_at)
+VALUES (?, ?, ?, ?, ?)
+`, gocql.UUID(newID), gocql.UUID(fieldID), sensorName, fmt.Sprintf("%d", sensorType), time.Now().UTC())
 
-	if err := db.Meta.Query(`
-INSERT INTO sensors (sensor_id, sensor_name, sensor_type)
-VALUES (?, ?, ?)
-`, gocql.UUID(newID), sensorName, fmt.Sprintf("%d", sensorType)).WithContext(ctx).Exec(); err != nil {
+	if err := batch.Exec(); err != nil {
 		return nil, err
 	}
 
@@ -178,15 +162,40 @@ VALUES (?, ?, ?)
 	}, nil
 }
 
+// sealPassword envelope-encrypts an MQTT password, bound to the sensor it belongs to.
+func (db *DB) sealPassword(sensorID gocql.UUID, password string) (keyID, sealed string, err error) {
+	return db.keyring.Seal([]byte(password), sensorID.Bytes())
+}
+
+// openPassword decrypts a stored MQTT password. Rows written before encryption was
+// introduced have no key ID and hold the password as is.
+func (db *DB) openPassword(sensorID gocql.UUID, keyID, stored string) (string, error) {
+	if keyID == "" || stored == "" {
+		return stored, nil
+	}
+
+	b, err := db.keyring.Open(keyID, stored, sensorID.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt MQTT password: %w", err)
+	}
+	return string(b), nil
+}
+
+// StoreSensorCredentials stores MQTT credentials, encrypting the password with the primary key.
 func (db *DB) StoreSensorCredentials(fieldID gocql.UUID, sensorID gocql.UUID, mqttUser, mqttPass string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
+	keyID, sealed, err := db.sealPassword(sensorID, mqttPass)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt MQTT password: %w", err)
+	}
+
 	query := db.Meta.Query(`
 UPDATE sensors_by_field
-SET mqtt_username = ?, mqtt_password = ?
+SET mqtt_username = ?, mqtt_password = ?, mqtt_key_id = ?
 WHERE field_id = ? AND sensor_id = ?
-`, mqttUser, mqttPass, fieldID, sensorID).WithContext(ctx)
+`, mqttUser, sealed, keyID, fieldID, sensorID).WithContext(ctx)
 
 	return query.Exec()
 }
@@ -198,27 +207,529 @@ type SensorCredentials struct {
 	MqttPass string `json:"password"`
 }
 
+// GetSensorCredentials returns the decrypted MQTT credentials of a sensor. Passwords that are
+// stored in plaintext or under a retired key are re-encrypted with the primary key on the way out.
 func (db *DB) GetSensorCredentials(sensorID uuid.UUID) (*SensorCredentials, error) {
+	fieldID, err := db.GetSensorFieldID(sensorID)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
-	query := db.Meta.Query(`
-SELECT mqtt_username, mqtt_password
+	row, err := db.getSensorRow(ctx, fieldID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := db.openPassword(gocql.UUID(sensorID), row.mqttKeyID, row.mqttPass)
+	if err != nil {
+		return nil, err
+	}
+
+	if password != "" && row.mqttKeyID != db.keyring.PrimaryID() {
+		if err := db.StoreSensorCredentials(gocql.UUID(fieldID), gocql.UUID(sensorID), row.mqttUser, password); err != nil {
+			db.logger.Warn().Err(err).Str("sensor_id", sensorID.String()).Msg("failed to re-encrypt MQTT password")
+		}
+	}
+
+	return &SensorCredentials{
+		MqttUser: row.mqttUser,
+		MqttPass: password,
+	}, nil
+}
+
+// ResealResult counts the stored MQTT passwords a ResealSensorCredentials run looked at.
+type ResealResult struct {
+	Scanned  int `json:"scanned"`
+	Resealed int `json:"resealed"`
+	// Remaining passwords are still in plaintext or under a retired key
+	Remaining int `json:"remaining"`
+}
+
+// ResealSensorCredentials walks sensors_by_field and re-encrypts every MQTT password that is
+// stored in plaintext or under a retired key with the primary key. With dryRun set it only
+// counts them.
+func (db *DB) ResealSensorCredentials(ctx context.Context, dryRun bool) (*ResealResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT field_id, sensor_id, mqtt_username, mqtt_password, mqtt_key_id
+FROM sensors_by_field
+`).WithContext(ctx).PageSize(1000).Iter()
+
+	var (
+		result                  ResealResult
+		fieldID, sensorID       gocql.UUID
+		username, stored, keyID string
+	)
+	for iter.Scan(&fieldID, &sensorID, &username, &stored, &keyID) {
+		// A static-only row has no sensor
+		if sensorID == (gocql.UUID{}) {
+			continue
+		}
+		result.Scanned++
+
+		if stored == "" || keyID == db.keyring.PrimaryID() {
+			continue
+		}
+		if dryRun {
+			result.Remaining++
+			continue
+		}
+
+		log := db.logger.With().Str("sensor_id", sensorID.String()).Logger()
+
+		password, err := db.openPassword(sensorID, keyID, stored)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to decrypt MQTT password for resealing")
+			result.Remaining++
+			continue
+		}
+		if err := db.StoreSensorCredentials(fieldID, sensorID, username, password); err != nil {
+			log.Warn().Err(err).Msg("failed to re-encrypt MQTT password")
+			result.Remaining++
+			continue
+		}
+		result.Resealed++
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetSensorFieldID returns the field a sensor is registered under. It is read by key from
+// sensors_meta.sensors; rows written before field_id was added there are looked up in
+// sensors_by_field once and backfilled.
+func (db *DB) GetSensorFieldID(sensorID uuid.UUID) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var fieldID gocql.UUID
+	err := db.Meta.Query(`
+SELECT field_id
+FROM sensors
+WHERE sensor_id = ?
+`, gocql.UUID(sensorID)).WithContext(ctx).Scan(&fieldID)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return uuid.Nil, ErrSensorNotFound
+		}
+		return uuid.Nil, err
+	}
+	if fieldID != (gocql.UUID{}) {
+		return uuid.UUID(fieldID), nil
+	}
+
+	err = db.Meta.Query(`
+SELECT field_id
 FROM sensors_by_field
 WHERE sensor_id = ?
 ALLOW FILTERING
-`, gocql.UUID(sensorID)).WithContext(ctx)
+`, gocql.UUID(sensorID)).WithContext(ctx).Scan(&fieldID)
+	if err != nil {
+		if err == gocql.ErrNotFound {
+			return uuid.Nil, ErrSensorNotFound
+		}
+		return uuid.Nil, err
+	}
+
+	if err := db.Meta.Query(`
+UPDATE sensors SET field_id = ?
+WHERE sensor_id = ?
+`, fieldID, gocql.UUID(sensorID)).WithContext(ctx).Exec(); err != nil {
+		db.logger.Warn().Err(err).Str("sensor_id", sensorID.String()).Msg("failed to backfill sensor field_id")
+	}
+
+	return uuid.UUID(fieldID), nil
+}
 
-	var username, password string
-	if err := query.Scan(&username, &password); err != nil {
+var ErrSensorDecommissioned = errors.New("sensor is decommissioned")
+
+// ErrSensorHasReadings rejects a type change that would strand the readings stored under
+// the current type.
+var ErrSensorHasReadings = errors.New("sensor type cannot change once the sensor has readings")
+
+// sensorRow is a full sensors_by_field row.
+type sensorRow struct {
+	sensorName       string
+	sensorType       string
+	mqttUser         string
+	mqttPass         string
+	mqttKeyID        string
+	decommissionedAt time.Time
+}
+
+func (db *DB) getSensorRow(ctx context.Context, fieldID, sensorID uuid.UUID) (*sensorRow, error) {
+	var row sensorRow
+	err := db.Meta.Query(`
+SELECT sensor_name, sensor_type, mqtt_username, mqtt_password, mqtt_key_id, decommissioned_at
+FROM sensors_by_field
+WHERE field_id = ? AND sensor_id = ?
+`, gocql.UUID(fieldID), gocql.UUID(sensorID)).WithContext(ctx).Scan(
+		&row.sensorName,
+		&row.sensorType,
+		&row.mqttUser,
+		&row.mqttPass,
+		&row.mqttKeyID,
+		&row.decommissionedAt,
+	)
+	if err != nil {
 		if err == gocql.ErrNotFound {
 			return nil, ErrSensorNotFound
 		}
 		return nil, err
 	}
 
+	return &row, nil
+}
+
+// sensorNameTaken reports whether another sensor in the field already uses sensorName.
+func (db *DB) sensorNameTaken(ctx context.Context, fieldID, sensorID uuid.UUID, sensorName string) (bool, error) {
+	iter := db.Meta.Query(`
+SELECT sensor_id, sensor_name FROM sensors_by_field
+WHERE field_id = ?
+`, gocql.UUID(fieldID)).WithContext(ctx).Iter()
+
+	var (
+		existingID   gocql.UUID
+		existingName string
+	)
+	for iter.Scan(&existingID, &existingName) {
+		if uuid.UUID(existingID) != sensorID && existingName == sensorName {
+			iter.Close()
+			return true, nil
+		}
+	}
+
+	return false, iter.Close()
+}
+
+// UpdateSensor renames a sensor and/or changes its type in both meta tables.
+// Nil arguments leave the corresponding column untouched. The type can only change
+// while the sensor has no readings.
+func (db *DB) UpdateSensor(fieldID, sensorID uuid.UUID, sensorName *string, sensorType *int) (*types.Sensor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	row, err := db.getSensorRow(ctx, fieldID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	if !row.decommissionedAt.IsZero() {
+		return nil, ErrSensorDecommissioned
+	}
+
+	name, sType := row.sensorName, row.sensorType
+	if sensorName != nil && *sensorName != name {
+		taken, err := db.sensorNameTaken(ctx, fieldID, sensorID, *sensorName)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, &SensorAlreadyExistsError{SensorName: *sensorName}
+		}
+		name = *sensorName
+	}
+	if sensorType != nil {
+		sType = fmt.Sprintf("%d", *sensorType)
+	}
+
+	parsed, err := parseSensorType(sType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Readings, cached latest values and aggregates are all keyed by type, a changed type
+	// would leave them unreachable
+	current, err := parseSensorType(row.sensorType)
+	if err != nil {
+		return nil, err
+	}
+	if current != parsed {
+		var registeredAt time.Time
+		err := db.Meta.Query(`
+SELECT registered_at FROM sensors
+WHERE sensor_id = ?
+`, gocql.UUID(sensorID)).WithContext(ctx).Scan(&registeredAt)
+		if err != nil && err != gocql.ErrNotFound {
+			return nil, err
+		}
+
+		// Each bucket query carries its own timeout
+		has, err := db.hasReadings(context.Background(), sensorID, int(current), registeredAt)
+		if err != nil {
+			return nil, err
+		}
+		if has {
+			return nil, ErrSensorHasReadings
+		}
+	}
+
+	// The readings check may have used up the lookup's deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+UPDATE sensors_by_field SET sensor_name = ?, sensor_type = ?
+WHERE field_id = ? AND sensor_id = ?
+`, name, sType, gocql.UUID(fieldID), gocql.UUID(sensorID))
+	batch.Query(`
+UPDATE sensors SET sensor_name = ?, sensor_type = ?
+WHERE sensor_id = ?
+`, name, sType, gocql.UUID(sensorID))
+
+	if err := batch.Exec(); err != nil {
+		return nil, err
+	}
+
+	return &types.Sensor{
+		SensorID:   sensorID,
+		SensorName: name,
+		SensorType: parsed,
+		FieldID:    &fieldID,
+	}, nil
+}
+
+// MoveSensor reassigns a sensor, including its MQTT credentials, to another field.
+func (db *DB) MoveSensor(fromFieldID, toFieldID, sensorID uuid.UUID) (*types.Sensor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	row, err := db.getSensorRow(ctx, fromFieldID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	if !row.decommissionedAt.IsZero() {
+		return nil, ErrSensorDecommissioned
+	}
+
+	taken, err := db.sensorNameTaken(ctx, toFieldID, sensorID, row.sensorName)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, &SensorAlreadyExistsError{SensorName: row.sensorName}
+	}
+
+	sType, err := parseSensorType(row.sensorType)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+DELETE FROM sensors_by_field
+WHERE field_id = ? AND sensor_id = ?
+`, gocql.UUID(fromFieldID), gocql.UUID(sensorID))
+	batch.Query(`
+INSERT INTO sensors_by_field (field_id, sensor_id, sensor_name, sensor_type, mqtt_username, mqtt_password, mqtt_key_id)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`, gocql.UUID(toFieldID), gocql.UUID(sensorID), row.sensorName, row.sensorType, row.mqttUser, row.mqttPass, row.mqttKeyID)
+	batch.Query(`
+UPDATE sensors SET field_id = ?
+WHERE sensor_id = ?
+`, gocql.UUID(toFieldID), gocql.UUID(sensorID))
+
+	if err := batch.Exec(); err != nil {
+		return nil, err
+	}
+
+	return &types.Sensor{
+		SensorID:   sensorID,
+		SensorName: row.sensorName,
+		SensorType: sType,
+		FieldID:    &toFieldID,
+	}, nil
+}
+
+// DecommissionSensor marks a sensor as retired in both meta tables and drops its MQTT password.
+// Rows are kept so the sensor's readings remain queryable. It returns the retired sensor and
+// its MQTT username so the caller can revoke it.
+func (db *DB) DecommissionSensor(fieldID, sensorID uuid.UUID) (*types.Sensor, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	row, err := db.getSensorRow(ctx, fieldID, sensorID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !row.decommissionedAt.IsZero() {
+		return nil, "", ErrSensorDecommissioned
+	}
+
+	sType, err := parseSensorType(row.sensorType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+UPDATE sensors_by_field SET decommissioned_at = ?, mqtt_password = null, mqtt_key_id = null
+WHERE field_id = ? AND sensor_id = ?
+`, now, gocql.UUID(fieldID), gocql.UUID(sensorID))
+	batch.Query(`
+UPDATE sensors SET decommissioned_at = ?
+WHERE sensor_id = ?
+`, now, gocql.UUID(sensorID))
+
+	if err := batch.Exec(); err != nil {
+		return nil, "", err
+	}
+
+	return &types.Sensor{
+		SensorID:         sensorID,
+		SensorName:       row.sensorName,
+		SensorType:       sType,
+		FieldID:          &fieldID,
+		DecommissionedAt: &now,
+	}, row.mqttUser, nil
+}
+
+// GetActiveSensorCredentials returns the MQTT credentials of a sensor that has not been decommissioned.
+func (db *DB) GetActiveSensorCredentials(fieldID, sensorID uuid.UUID) (*SensorCredentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	row, err := db.getSensorRow(ctx, fieldID, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	if !row.decommissionedAt.IsZero() {
+		return nil, ErrSensorDecommissioned
+	}
+
+	password, err := db.openPassword(gocql.UUID(sensorID), row.mqttKeyID, row.mqttPass)
+	if err != nil {
+		return nil, err
+	}
+
 	return &SensorCredentials{
-		MqttUser: username,
+		MqttUser: row.mqttUser,
 		MqttPass: password,
 	}, nil
 }
+
+// ClearSensorCredentials removes the stored MQTT credentials of a sensor.
+func (db *DB) ClearSensorCredentials(fieldID, sensorID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	return db.Meta.Query(`
+UPDATE sensors_by_field
+SET mqtt_username = null, mqtt_password = null, mqtt_key_id = null
+WHERE field_id = ? AND sensor_id = ?
+`, gocql.UUID(fieldID), gocql.UUID(sensorID)).WithContext(ctx).Exec()
+}
+
+// DeleteSensor removes a sensor from both meta tables. It backs out a failed registration.
+func (db *DB) DeleteSensor(fieldID, sensorID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	batch := db.Meta.Batch(gocql.LoggedBatch).WithContext(ctx)
+	batch.Query(`
+DELETE FROM sensors_by_field
+WHERE field_id = ? AND sensor_id = ?
+`, gocql.UUID(fieldID), gocql.UUID(sensorID))
+	batch.Query(`
+DELETE FROM sensors
+WHERE sensor_id = ?
+`, gocql.UUID(sensorID))
+
+	return batch.Exec()
+}
+
+// SensorAccount ties a registered sensor to its MQTT username.
+type SensorAccount struct {
+	FieldID        uuid.UUID
+	SensorID       uuid.UUID
+	MqttUser       string
+	Decommissioned bool
+}
+
+// ListSensorAccounts scans sensors_by_field for every sensor and its MQTT username.
+func (db *DB) ListSensorAccounts(ctx context.Context) ([]SensorAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT field_id, sensor_id, mqtt_username, decommissioned_at
+FROM sensors_by_field
+`).WithContext(ctx).PageSize(1000).Iter()
+
+	var (
+		accounts  []SensorAccount
+		fieldID   gocql.UUID
+		sensorID  gocql.UUID
+		mqttUser  string
+		retiredAt time.Time
+	)
+	for iter.Scan(&fieldID, &sensorID, &mqttUser, &retiredAt) {
+		// A static-only row has no sensor
+		if sensorID == (gocql.UUID{}) {
+			continue
+		}
+		accounts = append(accounts, SensorAccount{
+			FieldID:        uuid.UUID(fieldID),
+			SensorID:       uuid.UUID(sensorID),
+			MqttUser:       mqttUser,
+			Decommissioned: !retiredAt.IsZero(),
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// ListActiveSensors scans sensors_meta.sensors for every sensor that is not decommissioned.
+func (db *DB) ListActiveSensors(ctx context.Context) ([]types.Sensor, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	iter := db.Meta.Query(`
+SELECT sensor_id, sensor_name, sensor_type, decommissioned_at
+FROM sensors
+`).WithContext(ctx).PageSize(1000).Iter()
+
+	var (
+		sensors    []types.Sensor
+		sensorID   gocql.UUID
+		sensorName string
+		sensorType string
+		retiredAt  time.Time
+	)
+	for iter.Scan(&sensorID, &sensorName, &sensorType, &retiredAt) {
+		if !retiredAt.IsZero() {
+			continue
+		}
+
+		sType, err := parseSensorType(sensorType)
+		if err != nil {
+			continue
+		}
+
+		sensors = append(sensors, types.Sensor{
+			SensorID:   uuid.UUID(sensorID),
+			SensorName: sensorName,
+			SensorType: sType,
+		})
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return sensors, nil
+}
@@ -2,12 +2,17 @@ package emqx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type EmqxClient struct {
@@ -36,6 +41,19 @@ func New() *EmqxClient {
 	}
 }
 
+// sensorUsernamePattern matches usernames built by SensorUsername.
+var sensorUsernamePattern = regexp.MustCompile(`^[0-9a-f]{8}_.+$`)
+
+// SensorUsername is the MQTT username of a sensor: the first 8 characters of its ID and its name.
+func SensorUsername(sensorID uuid.UUID, sensorName string) string {
+	return fmt.Sprintf("%s_%s", sensorID.String()[:8], sensorName)
+}
+
+// IsSensorUsername reports whether a username follows the SensorUsername scheme.
+func IsSensorUsername(username string) bool {
+	return sensorUsernamePattern.MatchString(username)
+}
+
 type CreateUserResponse struct {
 	UserID      string `json:"user_id"`
 	IsSuperuser bool   `json:"is_superuser"`
@@ -81,3 +99,165 @@ func (c *EmqxClient) CreateUser(userID, password string, isSuperuser bool) (*Cre
 
 	return &result, nil
 }
+
+// DeleteUser removes a user from the built-in database authenticator.
+// A user that does not exist is treated as already deleted.
+func (c *EmqxClient) DeleteUser(userID string) error {
+	endpoint := fmt.Sprintf(
+		"http://%s/api/v5/authentication/password_based%%3Abuilt_in_database/users/%s",
+		c.BaseURL,
+		url.PathEscape(userID),
+	)
+
+	req, err := http.NewRequest(http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create EMQX request: %w", err)
+	}
+
+	req.SetBasicAuth(c.APIKey, c.APISecret)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact EMQX: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("emqx returned %s: %s", resp.Status, string(b))
+	}
+
+	return nil
+}
+
+// UpdatePassword replaces the password of an existing built-in database user.
+func (c *EmqxClient) UpdatePassword(userID, password string) error {
+	endpoint := fmt.Sprintf(
+		"http://%s/api/v5/authentication/password_based%%3Abuilt_in_database/users/%s",
+		c.BaseURL,
+		url.PathEscape(userID),
+	)
+
+	payload := map[string]any{
+		"password":     password,
+		"is_superuser": false,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode EMQX payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create EMQX request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.APIKey, c.APISecret)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact EMQX: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("emqx returned %s: %s", resp.Status, string(b))
+	}
+
+	return nil
+}
+
+type User struct {
+	UserID      string `json:"user_id"`
+	IsSuperuser bool   `json:"is_superuser"`
+}
+
+// ListUsers returns every user of the built-in database authenticator, following pagination.
+func (c *EmqxClient) ListUsers() ([]User, error) {
+	const limit = 500
+
+	var users []User
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf(
+			"http://%s/api/v5/authentication/password_based%%3Abuilt_in_database/users?page=%d&limit=%d",
+			c.BaseURL,
+			page,
+			limit,
+		)
+
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create EMQX request: %w", err)
+		}
+
+		req.SetBasicAuth(c.APIKey, c.APISecret)
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to contact EMQX: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("emqx returned %s: %s", resp.Status, string(b))
+		}
+
+		var result struct {
+			Data []User `json:"data"`
+			Meta struct {
+				HasNext bool `json:"hasnext"`
+			} `json:"meta"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid emqx response: %w", err)
+		}
+
+		users = append(users, result.Data...)
+		if !result.Meta.HasNext || len(result.Data) == 0 {
+			return users, nil
+		}
+	}
+}
+
+// Publish sends a message to topic through the EMQX HTTP API. A topic without
+// subscribers is not an error.
+func (c *EmqxClient) Publish(ctx context.Context, topic string, payload []byte, qos int) error {
+	endpoint := fmt.Sprintf("http://%s/api/v5/publish", c.BaseURL)
+
+	body, err := json.Marshal(map[string]any{
+		"topic":   topic,
+		"payload": string(payload),
+		"qos":     qos,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode EMQX payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create EMQX request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.APIKey, c.APISecret)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact EMQX: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 202 means the message was accepted but matched no subscriber
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("emqx returned %s: %s", resp.Status, string(b))
+	}
+
+	return nil
+}
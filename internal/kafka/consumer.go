@@ -0,0 +1,237 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+)
+
+const (
+	// generationTTL outlives any cached window aggregate, so a generation never
+	// expires while aggregates computed under it are still served.
+	generationTTL = 24 * time.Hour
+
+	topicRefreshInterval = 30 * time.Second
+)
+
+// CacheUpdater consumes the sensor topics and keeps the cache in step with the stream:
+// every reading is pushed to its sensor's latest values, and the window aggregates of
+// sensors that received readings are invalidated every flush interval.
+type CacheUpdater struct {
+	brokers       []string
+	group         string
+	cache         cache.Cache
+	keys          *cache.Keys
+	codec         *cache.Codec
+	flushInterval time.Duration
+	logger        zerolog.Logger
+
+	mu sync.Mutex
+	// dirty holds sensors that received readings since the last flush
+	dirty map[string]struct{}
+	// staleDays holds summaries of completed days that received late readings
+	staleDays map[string]struct{}
+}
+
+func NewCacheUpdater(brokers []string, group string, c cache.Cache, keys *cache.Keys, codec *cache.Codec, flushInterval time.Duration, logger zerolog.Logger) *CacheUpdater {
+	return &CacheUpdater{
+		brokers:       brokers,
+		group:         group,
+		cache:         c,
+		keys:          keys,
+		codec:         codec,
+		flushInterval: flushInterval,
+		logger:        logger,
+		dirty:         make(map[string]struct{}),
+		staleDays:     make(map[string]struct{}),
+	}
+}
+
+func (u *CacheUpdater) Run(ctx context.Context) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_8_0_0
+	// Only fresh readings matter, anything older is already in Scylla
+	cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+
+	client, err := sarama.NewClient(u.brokers, cfg)
+	if err != nil {
+		u.logger.Error().Err(err).Msg("Kafka client error, cache updates disabled")
+		return
+	}
+	defer client.Close()
+
+	group, err := sarama.NewConsumerGroupFromClient(u.group, client)
+	if err != nil {
+		u.logger.Error().Err(err).Str("group", u.group).Msg("failed to create consumer group, cache updates disabled")
+		return
+	}
+	defer group.Close()
+
+	go u.flushLoop(ctx)
+
+	for ctx.Err() == nil {
+		topics, err := sensorTopics(client)
+		if err != nil || len(topics) == 0 {
+			if err != nil {
+				u.logger.Error().Err(err).Msg("failed to list topics")
+			}
+			sleep(ctx, topicRefreshInterval)
+			continue
+		}
+
+		// Restart the session whenever sensor topics are added or removed
+		sessCtx, cancel := context.WithCancel(ctx)
+		go u.watchTopics(sessCtx, client, topics, cancel)
+
+		u.logger.Info().Strs("topics", topics).Msg("consuming sensor topics")
+		if err := group.Consume(sessCtx, topics, u); err != nil && !errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			u.logger.Error().Err(err).Msg("consumer group session failed")
+			sleep(ctx, time.Second)
+		}
+		cancel()
+	}
+}
+
+func (u *CacheUpdater) watchTopics(ctx context.Context, client sarama.Client, current []string, changed func()) {
+	ticker := time.NewTicker(topicRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := client.RefreshMetadata(); err != nil {
+				u.logger.Warn().Err(err).Msg("failed to refresh metadata")
+				continue
+			}
+			topics, err := sensorTopics(client)
+			if err == nil && !slices.Equal(topics, current) {
+				changed()
+				return
+			}
+		}
+	}
+}
+
+func (u *CacheUpdater) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (u *CacheUpdater) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (u *CacheUpdater) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			u.handle(sess.Context(), msg)
+			sess.MarkMessage(msg, "")
+		case <-sess.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (u *CacheUpdater) handle(ctx context.Context, msg *sarama.ConsumerMessage) {
+	r, err := parseReading(msg)
+	if err != nil {
+		metrics.CacheStreamReadingsTotal.WithLabelValues("invalid").Inc()
+		u.logger.Warn().Err(err).Str("topic", msg.Topic).Msg("invalid reading")
+		return
+	}
+
+	sensorID, sType, entry := r.SensorID, r.SensorType, r.Entry()
+	if err := u.cache.Store(ctx, u.keys.Latest(sensorID.String(), int(sType)), entry); err != nil {
+		metrics.CacheStreamReadingsTotal.WithLabelValues("error").Inc()
+		u.logger.Warn().Err(err).Str("sensor_id", sensorID.String()).Msg("failed to store reading in cache")
+	} else {
+		metrics.CacheStreamReadingsTotal.WithLabelValues("stored").Inc()
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	day := entry.Timestamp.Truncate(24 * time.Hour)
+
+	u.mu.Lock()
+	u.dirty[sensorID.String()] = struct{}{}
+	if day.Before(today) {
+		u.staleDays[u.keys.DaySummary(sensorID.String(), int(sType), day)] = struct{}{}
+	}
+	u.mu.Unlock()
+}
+
+func (u *CacheUpdater) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(u.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.flush(ctx)
+		}
+	}
+}
+
+// flush moves every dirty sensor to a new aggregate generation and drops day summaries
+// that late readings made stale. Batching per interval keeps aggregates of busy sensors
+// cacheable between flushes.
+func (u *CacheUpdater) flush(ctx context.Context) {
+	u.mu.Lock()
+	dirty, staleDays := u.dirty, u.staleDays
+	u.dirty = make(map[string]struct{})
+	u.staleDays = make(map[string]struct{})
+	u.mu.Unlock()
+
+	gen := time.Now().UnixNano()
+	for sensorID := range dirty {
+		key := u.keys.AggregateGeneration(sensorID)
+		if err := cache.Set(ctx, u.cache, u.codec, key, gen, generationTTL); err != nil {
+			u.logger.Warn().Err(err).Str("cache_key", key).Msg("failed to bump aggregate generation")
+			continue
+		}
+		metrics.CacheInvalidationsTotal.Inc()
+	}
+
+	if len(staleDays) > 0 {
+		keys := slices.Collect(maps.Keys(staleDays))
+		if err := u.cache.Delete(ctx, keys...); err != nil {
+			u.logger.Warn().Err(err).Int("keys", len(keys)).Msg("failed to delete stale day summaries")
+		}
+	}
+}
+
+// sensorTopics returns the sorted sensor topics known to the cluster.
+func sensorTopics(client sarama.Client) ([]string, error) {
+	topics, err := client.Topics()
+	if err != nil {
+		return nil, err
+	}
+
+	topics = slices.DeleteFunc(topics, func(t string) bool {
+		return !isKafkaManagedTopic(t)
+	})
+	slices.Sort(topics)
+	return topics, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
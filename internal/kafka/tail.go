@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+// Tail follows every partition of the sensor topics from the newest offset, without a
+// consumer group, so each API replica sees every reading. It backs the live streams.
+type Tail struct {
+	brokers []string
+	publish func(types.Reading)
+	logger  zerolog.Logger
+}
+
+func NewTail(brokers []string, publish func(types.Reading), logger zerolog.Logger) *Tail {
+	return &Tail{
+		brokers: brokers,
+		publish: publish,
+		logger:  logger,
+	}
+}
+
+func (t *Tail) Run(ctx context.Context) {
+	cfg := sarama.NewConfig()
+	cfg.Version = sarama.V2_8_0_0
+
+	client, err := sarama.NewClient(t.brokers, cfg)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("Kafka client error, live streams disabled")
+		return
+	}
+	defer client.Close()
+
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		t.logger.Error().Err(err).Msg("failed to create consumer, live streams disabled")
+		return
+	}
+	defer consumer.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// Partitions are tracked individually, one that failed to start is retried on the next
+	// refresh instead of being left behind with the rest of its topic
+	following := make(map[topicPartition]bool)
+	for {
+		topics, err := sensorTopics(client)
+		if err != nil {
+			t.logger.Error().Err(err).Msg("failed to list topics")
+		}
+
+		for _, topic := range topics {
+			partitions, err := client.Partitions(topic)
+			if err != nil {
+				t.logger.Warn().Err(err).Str("topic", topic).Msg("failed to list partitions")
+				continue
+			}
+
+			started := 0
+			for _, partition := range partitions {
+				tp := topicPartition{topic, partition}
+				if following[tp] {
+					continue
+				}
+
+				pc, err := consumer.ConsumePartition(topic, partition, sarama.OffsetNewest)
+				if err != nil {
+					t.logger.Warn().Err(err).Str("topic", topic).Int32("partition", partition).Msg("failed to consume partition")
+					continue
+				}
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					t.follow(ctx, pc)
+				}()
+				following[tp] = true
+				started++
+			}
+
+			if started > 0 {
+				t.logger.Info().Str("topic", topic).Int("partitions", started).Msg("following topic")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(topicRefreshInterval):
+		}
+
+		if err := client.RefreshMetadata(); err != nil {
+			t.logger.Warn().Err(err).Msg("failed to refresh metadata")
+		}
+	}
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+func (t *Tail) follow(ctx context.Context, pc sarama.PartitionConsumer) {
+	defer pc.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return
+			}
+
+			r, err := parseReading(msg)
+			if err != nil {
+				t.logger.Debug().Err(err).Str("topic", msg.Topic).Msg("invalid reading")
+				continue
+			}
+			t.publish(r)
+		}
+	}
+}
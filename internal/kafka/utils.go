@@ -1,6 +1,16 @@
 package kafka
 
-import "strings"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
 
 func isKafkaManagedTopic(topic string) bool {
 	for _, prefix := range []string{"temperatures_", "humidities_", "ph_levels_"} {
@@ -10,3 +20,47 @@ func isKafkaManagedTopic(topic string) bool {
 	}
 	return false
 }
+
+func topicSensorType(topic string) (types.SensorType, bool) {
+	switch {
+	case strings.HasPrefix(topic, "temperatures_"):
+		return types.SensorTypeTemperature, true
+	case strings.HasPrefix(topic, "humidities_"):
+		return types.SensorTypeHumidity, true
+	case strings.HasPrefix(topic, "ph_levels_"):
+		return types.SensorTypePHLevel, true
+	}
+	return 0, false
+}
+
+// message is the payload of the sensor topics, see arroyo.JSONSchema.
+type message struct {
+	SensorID  string    `json:"sensor_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// parseReading decodes a message from one of the sensor topics.
+func parseReading(msg *sarama.ConsumerMessage) (types.Reading, error) {
+	sType, ok := topicSensorType(msg.Topic)
+	if !ok {
+		return types.Reading{}, fmt.Errorf("not a sensor topic: %s", msg.Topic)
+	}
+
+	var m message
+	if err := json.Unmarshal(msg.Value, &m); err != nil {
+		return types.Reading{}, err
+	}
+
+	sensorID, err := uuid.Parse(m.SensorID)
+	if err != nil {
+		return types.Reading{}, fmt.Errorf("invalid sensor_id: %w", err)
+	}
+
+	return types.Reading{
+		SensorID:   sensorID,
+		SensorType: sType,
+		Timestamp:  m.Timestamp.UTC(),
+		Value:      m.Value,
+	}, nil
+}
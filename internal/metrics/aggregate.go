@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	AggregateCoalescedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "aggregate_coalesced_requests_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of aggregate requests served by a computation started by another request.",
+		},
+	)
+
+	AggregateStaleServedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "aggregate_stale_served_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of expired aggregates served while a refresh was in progress.",
+		},
+	)
+
+	AggregateRefreshesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "aggregate_refreshes_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of background aggregate refreshes by result.",
+		},
+		[]string{"result"},
+	)
+)
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	AlertTransitionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "alert_transitions_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of alerts starting or stopping to fire, by state and severity.",
+		},
+		[]string{"state", "severity"},
+	)
+
+	AlertsFiring = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "alerts_firing",
+			Namespace: NostradamusNamespace,
+			Help:      "The number of rule and sensor pairs currently firing.",
+		},
+	)
+
+	AlertReadingsDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "alert_readings_dropped_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of readings not evaluated because the alert evaluator fell behind.",
+		},
+	)
+)
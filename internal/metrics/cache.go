@@ -12,7 +12,7 @@ var (
 			Namespace: NostradamusNamespace,
 			Help:      "The total number of cache misses since the application started.",
 		},
-		[]string{"driver"},
+		[]string{"driver", "tier"},
 	)
 
 	CacheHitsTotal = promauto.NewCounterVec(
@@ -21,7 +21,7 @@ var (
 			Namespace: NostradamusNamespace,
 			Help:      "The total number of cache hits since the application started.",
 		},
-		[]string{"driver"},
+		[]string{"driver", "tier"},
 	)
 
 	cacheBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05}
@@ -33,7 +33,7 @@ var (
 			Buckets:   cacheBuckets,
 			Help:      "The latency of cache read operations in seconds.",
 		},
-		[]string{"driver"},
+		[]string{"driver", "tier"},
 	)
 
 	CacheWriteLatencySeconds = promauto.NewHistogramVec(
@@ -43,6 +43,67 @@ var (
 			Buckets:   cacheBuckets,
 			Help:      "The latency of cache write operations in seconds.",
 		},
+		[]string{"driver", "tier"},
+	)
+)
+
+var (
+	CacheStreamReadingsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "cache_stream_readings_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of readings consumed from Kafka to update the cache, by result.",
+		},
+		[]string{"result"},
+	)
+
+	CacheInvalidationsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "cache_invalidations_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of sensors whose cached aggregates were invalidated by new readings.",
+		},
+	)
+)
+
+var (
+	NegativeCacheHitsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "negative_cache_hits_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of requests answered from a negative cache entry, by reason.",
+		},
+		[]string{"reason"},
+	)
+
+	NegativeCacheMissesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "negative_cache_misses_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of negative outcomes computed from the database and then cached, by reason.",
+		},
+		[]string{"reason"},
+	)
+)
+
+var (
+	CacheWarmupSensors = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "cache_warmup_sensors",
+			Namespace: NostradamusNamespace,
+			Help:      "Progress of the current or last cache warm-up run, by state (total, done, failed).",
+		},
+		[]string{"state"},
+	)
+)
+
+var (
+	CacheCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "cache_circuit_state",
+			Namespace: NostradamusNamespace,
+			Help:      "State of the cache circuit breaker: 0 closed, 1 half-open, 2 open.",
+		},
 		[]string{"driver"},
 	)
 )
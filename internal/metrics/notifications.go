@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	NotificationAttemptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "notification_attempts_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of notification delivery attempts, by channel type and result.",
+		},
+		[]string{"type", "result"},
+	)
+
+	NotificationDeadLettersTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "notification_dead_letters_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of notifications given up on after exhausting their attempts, by channel type.",
+		},
+		[]string{"type"},
+	)
+
+	NotificationsDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "notifications_dropped_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of alert events not notified because the dispatcher fell behind.",
+		},
+	)
+)
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	StreamClients = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "stream_clients",
+			Namespace: NostradamusNamespace,
+			Help:      "The number of connected live stream clients, by transport.",
+		},
+		[]string{"transport"},
+	)
+
+	StreamLaggedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "stream_lagged_total",
+			Namespace: NostradamusNamespace,
+			Help:      "The total number of live stream subscribers dropped for falling behind.",
+		},
+	)
+)
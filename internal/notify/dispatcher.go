@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/emqx"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+const (
+	// queueSize is how many alert events may wait for delivery before new ones are dropped.
+	queueSize = 1024
+	// maxDeliveries bounds the delivery attempts in flight. Deliveries waiting out their
+	// backoff hold no slot.
+	maxDeliveries = 32
+	// maxBackoff caps the doubling delay between attempts.
+	maxBackoff = 5 * time.Minute
+)
+
+// Store is the part of db.DB the dispatcher needs.
+type Store interface {
+	ListNotificationChannels(ctx context.Context, userID uuid.UUID) ([]types.NotificationChannel, error)
+	RecordDeadLetter(ctx context.Context, letter types.DeadLetter) error
+}
+
+var _ Store = (*db.DB)(nil)
+
+// delivery is an alert event on its way to one channel.
+type delivery struct {
+	ch types.NotificationChannel
+	n  types.AlertNotification
+	// attempts made so far, and the delay before the next one
+	attempts int
+	backoff  time.Duration
+}
+
+// Dispatcher delivers alert events to the enabled channels of the rule's owner. Failed
+// deliveries are retried with exponential backoff up to the channel's attempts, then
+// recorded as dead letters.
+type Dispatcher struct {
+	Store     Store
+	sender    *Sender
+	queue     chan types.AlertNotification
+	sem       chan struct{}
+	wg        sync.WaitGroup
+	cancelCtx context.CancelFunc
+	// stopped is closed once the dispatch loop has exited
+	stopped chan struct{}
+	logger  zerolog.Logger
+}
+
+// NewDispatcher creates a new background worker for alert notifications. ec may be nil,
+// in which case MQTT channels fail.
+func NewDispatcher(store Store, ec *emqx.EmqxClient, logger zerolog.Logger) *Dispatcher {
+	return &Dispatcher{
+		Store:  store,
+		sender: NewSender(ec),
+		queue:  make(chan types.AlertNotification, queueSize),
+		sem:    make(chan struct{}, maxDeliveries),
+		logger: logger,
+	}
+}
+
+// Notify queues an alert event for delivery. It never blocks; events arriving while the
+// queue is full are dropped.
+func (d *Dispatcher) Notify(rule types.AlertRule, event types.AlertEvent) {
+	select {
+	case d.queue <- types.AlertNotification{Event: event, Rule: rule}:
+	default:
+		metrics.NotificationsDroppedTotal.Inc()
+		d.logger.Warn().Str("rule_id", rule.RuleID.String()).Msg("notification queue full, dropping alert event")
+	}
+}
+
+func (d *Dispatcher) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	d.cancelCtx = cancel
+	d.stopped = make(chan struct{})
+
+	go func() {
+		defer close(d.stopped)
+		d.logger.Info().Msg("notification dispatch started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				d.logger.Info().Msg("notification dispatch stopped")
+				return
+			case n := <-d.queue:
+				d.dispatch(ctx, n)
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the background worker. Deliveries still waiting to be retried
+// are dead lettered.
+func (d *Dispatcher) Stop() {
+	if d.cancelCtx != nil {
+		d.cancelCtx()
+		// The dispatch loop adds deliveries to wg, it must be gone before waiting on it
+		<-d.stopped
+	}
+	d.wg.Wait()
+}
+
+// dispatch fans n out to the channels of the rule's owner. Channels scoped to a field
+// only receive the events of that field.
+func (d *Dispatcher) dispatch(ctx context.Context, n types.AlertNotification) {
+	channels, err := d.Store.ListNotificationChannels(ctx, n.Rule.UserID)
+	if err != nil {
+		d.logger.Error().Err(err).Str("rule_id", n.Rule.RuleID.String()).Msg("failed to load notification channels")
+		return
+	}
+
+	for _, ch := range channels {
+		if !ch.Enabled || (ch.FieldID != nil && *ch.FieldID != n.Event.FieldID) {
+			continue
+		}
+
+		d.wg.Add(1)
+		d.deliver(ctx, &delivery{
+			ch:      ch,
+			n:       n,
+			backoff: time.Duration(ch.Backoff),
+		})
+	}
+}
+
+// deliver makes the next attempt of dl once a slot is free. A failed attempt releases
+// its slot and schedules a retry, until the delivery fails permanently or runs out of
+// attempts. The caller must have added dl to wg.
+func (d *Dispatcher) deliver(ctx context.Context, dl *delivery) {
+	select {
+	case d.sem <- struct{}{}:
+	case <-ctx.Done():
+		d.deadLetter(dl, ctx.Err())
+		d.wg.Done()
+		return
+	}
+
+	go func() {
+		err := d.sender.Send(ctx, dl.ch, dl.n)
+		<-d.sem
+		dl.attempts++
+
+		if err == nil {
+			metrics.NotificationAttemptsTotal.WithLabelValues(string(dl.ch.Type), "ok").Inc()
+			d.logger.Debug().Str("channel_id", dl.ch.ChannelID.String()).Int("attempt", dl.attempts).Msg("notification delivered")
+			d.wg.Done()
+			return
+		}
+		metrics.NotificationAttemptsTotal.WithLabelValues(string(dl.ch.Type), "error").Inc()
+
+		var perm *PermanentError
+		if errors.As(err, &perm) || dl.attempts >= max(dl.ch.MaxAttempts, 1) {
+			d.deadLetter(dl, err)
+			d.wg.Done()
+			return
+		}
+
+		wait := dl.backoff
+		dl.backoff = min(2*dl.backoff, maxBackoff)
+
+		d.logger.Warn().Err(err).
+			Str("channel_id", dl.ch.ChannelID.String()).
+			Int("attempt", dl.attempts).
+			Dur("retry_in", wait).
+			Msg("notification delivery failed, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			d.deliver(ctx, dl)
+		case <-ctx.Done():
+			// On shutdown the remaining attempts are given up
+			timer.Stop()
+			d.deadLetter(dl, err)
+			d.wg.Done()
+		}
+	}()
+}
+
+// deadLetter records a delivery that is given up on.
+func (d *Dispatcher) deadLetter(dl *delivery, err error) {
+	log := d.logger.With().
+		Str("channel_id", dl.ch.ChannelID.String()).
+		Str("rule_id", dl.n.Rule.RuleID.String()).
+		Str("event_id", dl.n.Event.EventID.String()).
+		Logger()
+
+	log.Error().Err(err).Int("attempts", dl.attempts).Msg("notification delivery failed, dead lettering")
+	metrics.NotificationDeadLettersTotal.WithLabelValues(string(dl.ch.Type)).Inc()
+
+	payload, _ := json.Marshal(dl.n)
+	letter := types.DeadLetter{
+		ChannelID: dl.ch.ChannelID,
+		RuleID:    dl.n.Rule.RuleID,
+		EventID:   dl.n.Event.EventID,
+		Payload:   string(payload),
+		Error:     err.Error(),
+		Attempts:  dl.attempts,
+		Timestamp: time.Now().UTC(),
+	}
+	// The dispatch context may be done already, the record outlives it
+	if err := d.Store.RecordDeadLetter(context.Background(), letter); err != nil {
+		log.Error().Err(err).Msg("failed to record dead letter")
+	}
+}
+
+// TestNotification builds a synthetic firing event for ch, for checking its delivery.
+func TestNotification(ch types.NotificationChannel) types.AlertNotification {
+	now := time.Now().UTC()
+	fieldID := uuid.Nil
+	if ch.FieldID != nil {
+		fieldID = *ch.FieldID
+	}
+
+	return types.AlertNotification{
+		Event: types.AlertEvent{
+			EventID:    uuid.New(),
+			FieldID:    fieldID,
+			State:      types.AlertStateFiring,
+			Severity:   types.AlertSeverityInfo,
+			Comparator: types.AlertComparatorGT,
+			Threshold:  0,
+			Value:      1,
+			Timestamp:  now,
+		},
+		Rule: types.AlertRule{
+			UserID:     ch.UserID,
+			FieldID:    fieldID,
+			Comparator: types.AlertComparatorGT,
+			Severity:   types.AlertSeverityInfo,
+			Enabled:    true,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+		Test: true,
+	}
+}
@@ -0,0 +1,256 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+type fakeStore struct {
+	channels []types.NotificationChannel
+
+	mu      sync.Mutex
+	letters []types.DeadLetter
+}
+
+func (s *fakeStore) ListNotificationChannels(ctx context.Context, userID uuid.UUID) ([]types.NotificationChannel, error) {
+	return s.channels, nil
+}
+
+func (s *fakeStore) RecordDeadLetter(ctx context.Context, letter types.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, letter)
+	return nil
+}
+
+func newTestDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		Store:  store,
+		sender: newSender(nil, nil),
+		queue:  make(chan types.AlertNotification, queueSize),
+		sem:    make(chan struct{}, maxDeliveries),
+		logger: zerolog.Nop(),
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxAttempts  int
+		wantRequests int
+		wantLetter   bool
+	}{
+		{"delivered", []int{200}, 3, 1, false},
+		{"retried until delivered", []int{503, 429, 200}, 3, 3, false},
+		{"retries exhausted", []int{500}, 3, 3, true},
+		{"single attempt", []int{503}, 1, 1, true},
+		{"permanent failure", []int{400}, 5, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer srv.Close()
+
+			ch := types.NotificationChannel{
+				ChannelID:   uuid.New(),
+				Type:        types.NotificationChannelWebhook,
+				URL:         srv.URL,
+				Secret:      "s3cret",
+				MaxAttempts: tt.maxAttempts,
+				Backoff:     types.Duration(time.Millisecond),
+				Enabled:     true,
+			}
+			store := &fakeStore{channels: []types.NotificationChannel{ch}}
+			d := newTestDispatcher(store)
+
+			n := TestNotification(ch)
+			d.dispatch(context.Background(), n)
+			d.wg.Wait()
+
+			if got := int(requests.Load()); got != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", got, tt.wantRequests)
+			}
+
+			if !tt.wantLetter {
+				if len(store.letters) != 0 {
+					t.Fatalf("dead letters = %+v, want none", store.letters)
+				}
+				return
+			}
+			if len(store.letters) != 1 {
+				t.Fatalf("dead letters = %d, want 1", len(store.letters))
+			}
+			letter := store.letters[0]
+			if letter.ChannelID != ch.ChannelID || letter.EventID != n.Event.EventID {
+				t.Fatalf("dead letter = %+v, not for the delivered event", letter)
+			}
+			if letter.Attempts != tt.wantRequests {
+				t.Fatalf("dead letter attempts = %d, want %d", letter.Attempts, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestDispatchSkipsChannels(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	fieldID, otherField := uuid.New(), uuid.New()
+	channel := func(enabled bool, field *uuid.UUID) types.NotificationChannel {
+		return types.NotificationChannel{
+			ChannelID:   uuid.New(),
+			Type:        types.NotificationChannelWebhook,
+			URL:         srv.URL,
+			MaxAttempts: 1,
+			Enabled:     enabled,
+			FieldID:     field,
+		}
+	}
+
+	store := &fakeStore{channels: []types.NotificationChannel{
+		channel(true, nil),
+		channel(true, &fieldID),
+		channel(false, nil),
+		channel(true, &otherField),
+	}}
+	d := newTestDispatcher(store)
+
+	n := TestNotification(types.NotificationChannel{FieldID: &fieldID})
+	d.dispatch(context.Background(), n)
+	d.wg.Wait()
+
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("requests = %d, want 2", got)
+	}
+}
+
+func TestRetriesHoldNoSlot(t *testing.T) {
+	var requests atomic.Int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer healthy.Close()
+
+	var channels []types.NotificationChannel
+	for range maxDeliveries {
+		channels = append(channels, types.NotificationChannel{
+			ChannelID:   uuid.New(),
+			Type:        types.NotificationChannelWebhook,
+			URL:         failing.URL,
+			MaxAttempts: 2,
+			Backoff:     types.Duration(time.Hour),
+			Enabled:     true,
+		})
+	}
+	channels = append(channels, types.NotificationChannel{
+		ChannelID:   uuid.New(),
+		Type:        types.NotificationChannelWebhook,
+		URL:         healthy.URL,
+		MaxAttempts: 1,
+		Enabled:     true,
+	})
+
+	store := &fakeStore{channels: channels}
+	d := newTestDispatcher(store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.dispatch(ctx, TestNotification(types.NotificationChannel{}))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("dispatch blocked on deliveries waiting out their backoff")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if requests.Load() != 1 {
+		t.Fatal("healthy channel was not delivered to")
+	}
+
+	// Shutting down dead letters every pending retry. The healthy delivery may still be
+	// reading its response when the context is cancelled, so only failing channels count.
+	cancel()
+	d.wg.Wait()
+	healthyID := channels[len(channels)-1].ChannelID
+	pending := 0
+	for _, letter := range store.letters {
+		if letter.ChannelID != healthyID {
+			pending++
+		}
+	}
+	if pending != maxDeliveries {
+		t.Fatalf("dead letters = %d, want %d", pending, maxDeliveries)
+	}
+}
+
+func TestStopDrainsDispatch(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{channels: []types.NotificationChannel{{
+		ChannelID:   uuid.New(),
+		Type:        types.NotificationChannelWebhook,
+		URL:         srv.URL,
+		MaxAttempts: 3,
+		Backoff:     types.Duration(time.Hour),
+		Enabled:     true,
+	}}}
+	d := newTestDispatcher(store)
+	d.Start(context.Background())
+
+	ch := types.NotificationChannel{}
+	for range 10 {
+		n := TestNotification(ch)
+		d.Notify(n.Rule, n.Event)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for requests.Load() < 10 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := requests.Load(); got != 10 {
+		t.Fatalf("requests = %d, want 10", got)
+	}
+
+	d.Stop()
+
+	// Every delivery is waiting out its backoff and dead lettered by Stop
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.letters) != 10 {
+		t.Fatalf("dead letters = %d, want 10", len(store.letters))
+	}
+}
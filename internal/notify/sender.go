@@ -0,0 +1,282 @@
+// Package notify delivers alert events to the notification channels of their owners.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ntentasd/nostradamus-api/internal/emqx"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of webhook deliveries as
+	// t=<unix seconds>,v1=<hex digest>, see Sign.
+	SignatureHeader = "X-Nostradamus-Signature"
+	EventHeader     = "X-Nostradamus-Event"
+
+	// AlertTopicPrefix is followed by the field ID on MQTT channels.
+	AlertTopicPrefix = "alerts/"
+)
+
+// PermanentError marks a failed delivery that retrying will not fix, like a webhook
+// rejecting the request.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret. Receivers
+// recompute it to authenticate deliveries and reject old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var errDestinationNotAllowed = errors.New("destination address is not allowed")
+
+// blockedPrefixes are special purpose ranges not covered by the netip.Addr predicates.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// publicAddr reports whether addr may be reached by webhook and Slack deliveries.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialControl refuses connections to loopback, private, link-local and other non public
+// addresses. It runs after DNS resolution, so a name resolving to an internal address,
+// even only on a later lookup, is refused too.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !publicAddr(addr) {
+		return errDestinationNotAllowed
+	}
+	return nil
+}
+
+// CheckURL validates the endpoint of a webhook or Slack channel. Hosts that are internal
+// by their name or address are rejected early; dialControl enforces the rest at connect
+// time.
+func CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return errors.New("url must be an absolute https url")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") || strings.HasSuffix(host, ".local") {
+		return errDestinationNotAllowed
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !publicAddr(addr) {
+		return errDestinationNotAllowed
+	}
+
+	return nil
+}
+
+// Sender performs single delivery attempts.
+type Sender struct {
+	client *http.Client
+	emqx   *emqx.EmqxClient
+}
+
+// NewSender returns a Sender that only connects to public addresses.
+func NewSender(ec *emqx.EmqxClient) *Sender {
+	return newSender(ec, dialControl)
+}
+
+func newSender(ec *emqx.EmqxClient, control func(network, address string, c syscall.RawConn) error) *Sender {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: control,
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				// No proxy, it would make the connect time check see the proxy instead
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// A redirect could move a signed payload to another host
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		emqx: ec,
+	}
+}
+
+// Send delivers n to ch once.
+func (s *Sender) Send(ctx context.Context, ch types.NotificationChannel, n types.AlertNotification) error {
+	switch ch.Type {
+	case types.NotificationChannelWebhook:
+		return s.webhook(ctx, ch, n)
+	case types.NotificationChannelSlack:
+		return s.slack(ctx, ch, n)
+	case types.NotificationChannelMQTT:
+		return s.mqtt(ctx, n)
+	default:
+		return &PermanentError{fmt.Errorf("unknown channel type %q", ch.Type)}
+	}
+}
+
+func (s *Sender) webhook(ctx context.Context, ch types.NotificationChannel, n types.AlertNotification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return &PermanentError{err}
+	}
+
+	header := http.Header{}
+	header.Set(EventHeader, string(n.Event.State))
+	if ch.Secret != "" {
+		ts := time.Now().Unix()
+		header.Set(SignatureHeader, fmt.Sprintf("t=%d,v1=%s", ts, Sign(ch.Secret, ts, body)))
+	}
+
+	return s.post(ctx, ch.URL, header, body)
+}
+
+func (s *Sender) slack(ctx context.Context, ch types.NotificationChannel, n types.AlertNotification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": slackText(n),
+	})
+	if err != nil {
+		return &PermanentError{err}
+	}
+
+	return s.post(ctx, ch.URL, http.Header{}, body)
+}
+
+// slackText renders a one line summary, e.g.
+// "[critical] firing: sensor 1b4e... read 8.9, gt 8.5 (rule 7c2f...)".
+func slackText(n types.AlertNotification) string {
+	var b strings.Builder
+	if n.Test {
+		b.WriteString("Test notification: ")
+	}
+	fmt.Fprintf(&b, "[%s] %s: sensor %s read %g, %s %g (rule %s)",
+		n.Event.Severity,
+		n.Event.State,
+		n.Event.SensorID,
+		n.Event.Value,
+		n.Event.Comparator,
+		n.Event.Threshold,
+		n.Event.RuleID,
+	)
+	return b.String()
+}
+
+func (s *Sender) mqtt(ctx context.Context, n types.AlertNotification) error {
+	if s.emqx == nil {
+		return &PermanentError{errors.New("mqtt channels are not available")}
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return &PermanentError{err}
+	}
+
+	// The broker's reply describes internal infrastructure, keep it out of the error
+	if err := s.emqx.Publish(ctx, AlertTopicPrefix+n.Event.FieldID.String(), body, 1); err != nil {
+		return errors.New("failed to publish to the MQTT broker")
+	}
+	return nil
+}
+
+// post sends a JSON body. Server errors, timeouts and rate limits are retryable, any
+// other non 2xx response is permanent. Errors never carry the response body or the
+// resolved address, as they are shown to API callers.
+func (s *Sender) post(ctx context.Context, endpoint string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{err}
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nostradamus-api")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return requestError(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+
+	switch {
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return err
+	default:
+		return &PermanentError{err}
+	}
+}
+
+// requestError reduces a failed request to its class.
+func requestError(err error) error {
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+	)
+	switch {
+	case errors.Is(err, errDestinationNotAllowed):
+		return &PermanentError{errDestinationNotAllowed}
+	case errors.As(err, &dnsErr):
+		return errors.New("could not resolve host")
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errors.New("request timed out")
+	default:
+		return errors.New("connection failed")
+	}
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/ntentasd/nostradamus-api/internal/auth"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+// cacheVersionHandler reports (GET) or bumps (POST) the cache namespace version.
+// Bumping orphans every cached key on all replicas, which pick it up within a few seconds.
+func (app *App) cacheVersionHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.authorizeAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		utils.ReplyJSON(w, http.StatusOK, utils.Body{
+			"version": app.Keys.Version(),
+		})
+	case http.MethodPost:
+		version, err := app.Keys.Bump(r.Context(), app.Cache)
+		if err != nil {
+			app.logger.Error().Err(err).Msg("failed to bump cache version")
+			utils.ReplyInternalServerError(w, err.Error())
+			return
+		}
+
+		p, _ := auth.FromContext(r.Context())
+		app.logger.Info().Int64("version", version).Str("actor_id", p.UserID.String()).Msg("cache version bumped")
+
+		utils.ReplyJSON(w, http.StatusOK, utils.Body{
+			"version": version,
+		})
+	default:
+		utils.ReplyMethodNotAllowed(w)
+	}
+}
+
+// credentialsResealHandler reports (GET) or re-encrypts (POST) the stored MQTT passwords
+// that are still in plaintext or under a retired key. Re-encryption otherwise only happens
+// when a sensor's credentials are read.
+func (app *App) credentialsResealHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.authorizeAdmin(w, r) {
+		return
+	}
+
+	var dryRun bool
+	switch r.Method {
+	case http.MethodGet:
+		dryRun = true
+	case http.MethodPost:
+	default:
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	result, err := app.Store.ResealSensorCredentials(r.Context(), dryRun)
+	if err != nil {
+		app.logger.Error().Err(err).Msg("failed to reseal sensor credentials")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	if !dryRun {
+		p, _ := auth.FromContext(r.Context())
+		app.logger.Info().
+			Int("resealed", result.Resealed).
+			Int("remaining", result.Remaining).
+			Str("actor_id", p.UserID.String()).
+			Msg("sensor credentials resealed")
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": result,
+	})
+}
@@ -0,0 +1,205 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+// aggregateTTL is how long a computed aggregate is considered fresh.
+const aggregateTTL = 5 * time.Minute
+
+var (
+	errNoReadings    = errors.New("no readings found")
+	errUnknownSensor = errors.New("sensor not found")
+)
+
+// cachedAggregate is the cache representation of an aggregate. Entries outlive their
+// freshness by the configured stale window so they can be served while being refreshed.
+type cachedAggregate struct {
+	Data       types.Aggregate `json:"data"`
+	FreshUntil time.Time       `json:"fresh_until"`
+	// Missing marks a negative entry and holds the reason no aggregate exists
+	Missing string `json:"missing,omitempty"`
+}
+
+// aggregateRequest identifies one aggregate computation.
+type aggregateRequest struct {
+	cacheKey  string
+	sensorID  string
+	sType     int
+	window    time.Duration
+	requested []string
+}
+
+// aggregate returns the aggregate of a sensor over the trailing window, from the cache
+// when possible. Sensors without one return errNoReadings or errUnknownSensor.
+func (app *App) aggregate(ctx context.Context, sensorID string, sType int, window time.Duration, requested []string, bypass bool) (types.Aggregate, error) {
+	span := trace.SpanFromContext(ctx)
+
+	now := time.Now().UTC()
+	gen := app.aggregateGeneration(ctx, sensorID)
+	// Key on the parsed window so 24h and 1440m share an entry
+	cacheKey := app.Keys.Aggregate(sensorID, sType, now, window.String(), gen, requested)
+
+	req := aggregateRequest{
+		cacheKey:  cacheKey,
+		sensorID:  sensorID,
+		sType:     sType,
+		window:    window,
+		requested: requested,
+	}
+
+	if entry, fresh := app.lookupAggregate(ctx, req); entry != nil {
+		if entry.Missing != "" {
+			// Negative entries are never served stale
+			if fresh && !bypass {
+				metrics.NegativeCacheHitsTotal.WithLabelValues(entry.Missing).Inc()
+				span.SetAttributes(attribute.String("cache.result", "negative"))
+				if entry.Missing == reasonUnknownSensor {
+					return types.Aggregate{}, errUnknownSensor
+				}
+				return types.Aggregate{}, errNoReadings
+			}
+		} else if fresh || app.config.aggregateStaleTTL > 0 {
+			if !fresh {
+				metrics.AggregateStaleServedTotal.Inc()
+				span.SetAttributes(attribute.String("cache.result", "stale"))
+				app.refreshAggregate(ctx, req)
+			}
+			return entry.Data, nil
+		}
+	}
+
+	return app.loadAggregate(ctx, req)
+}
+
+// aggregateGeneration returns the current aggregate generation of a sensor, or 0 when
+// no readings have been streamed for it recently.
+func (app *App) aggregateGeneration(ctx context.Context, sensorID string) int64 {
+	gen, err := cache.Get[int64](ctx, app.Cache, app.Codec, app.Keys.AggregateGeneration(sensorID))
+	if errors.Is(err, cache.ErrCorrupt) {
+		app.logger.Warn().Err(err).Str("sensor_id", sensorID).Msg("invalid aggregate generation")
+	}
+	if err != nil {
+		return 0
+	}
+
+	return gen
+}
+
+// lookupAggregate returns the cached aggregate for req and whether it is still fresh.
+func (app *App) lookupAggregate(ctx context.Context, req aggregateRequest) (*cachedAggregate, bool) {
+	entry, err := cache.Get[cachedAggregate](ctx, app.Cache, app.Codec, req.cacheKey)
+	if errors.Is(err, cache.ErrCorrupt) {
+		app.logger.Warn().Err(err).Str("cache_key", req.cacheKey).Msg("invalid cache entry")
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	return &entry, time.Now().Before(entry.FreshUntil)
+}
+
+// loadAggregate computes the aggregate for req, coalescing concurrent callers for the
+// same cache key into a single computation.
+func (app *App) loadAggregate(ctx context.Context, req aggregateRequest) (types.Aggregate, error) {
+	// The computation is shared, so it must not be cancelled when the first caller goes away
+	v, err, shared := app.aggregates.Do(req.cacheKey, func() (any, error) {
+		return app.computeAggregate(context.WithoutCancel(ctx), req)
+	})
+	if shared {
+		metrics.AggregateCoalescedTotal.Inc()
+	}
+	if err != nil {
+		return types.Aggregate{}, err
+	}
+
+	return v.(types.Aggregate), nil
+}
+
+// refreshAggregate recomputes req in the background unless a computation for the same
+// key is already running.
+func (app *App) refreshAggregate(ctx context.Context, req aggregateRequest) {
+	ch := app.aggregates.DoChan(req.cacheKey, func() (any, error) {
+		return app.computeAggregate(context.WithoutCancel(ctx), req)
+	})
+
+	go func() {
+		res := <-ch
+		switch {
+		case res.Shared:
+			metrics.AggregateCoalescedTotal.Inc()
+		case res.Err != nil && !errors.Is(res.Err, errNoReadings) && !errors.Is(res.Err, errUnknownSensor):
+			metrics.AggregateRefreshesTotal.WithLabelValues("error").Inc()
+			app.logger.Error().Err(res.Err).Str("cache_key", req.cacheKey).Msg("failed to refresh aggregate")
+		default:
+			metrics.AggregateRefreshesTotal.WithLabelValues("ok").Inc()
+		}
+	}()
+}
+
+// storeMissingAggregate caches why req has no aggregate and returns the matching error.
+func (app *App) storeMissingAggregate(ctx context.Context, req aggregateRequest) error {
+	reason, missingErr := reasonNoReadings, errNoReadings
+
+	// The handler validated the ID already
+	sensorID, _ := uuid.Parse(req.sensorID)
+	if _, err := app.Store.GetSensorType(ctx, sensorID); errors.Is(err, db.ErrSensorNotFound) {
+		reason, missingErr = reasonUnknownSensor, errUnknownSensor
+	}
+
+	metrics.NegativeCacheMissesTotal.WithLabelValues(reason).Inc()
+
+	entry := cachedAggregate{
+		FreshUntil: time.Now().Add(negativeTTL),
+		Missing:    reason,
+	}
+	if err := cache.Set(ctx, app.Cache, app.Codec, req.cacheKey, entry, negativeTTL); err != nil {
+		app.logger.Warn().Err(err).Str("cache_key", req.cacheKey).Msg("failed to store negative cache entry")
+	}
+
+	return missingErr
+}
+
+func (app *App) computeAggregate(ctx context.Context, req aggregateRequest) (types.Aggregate, error) {
+	now := time.Now().UTC()
+
+	summary, err := app.summarize(ctx, req.sensorID, req.sType, now.Add(-req.window), now)
+	if err != nil {
+		return types.Aggregate{}, err
+	}
+
+	if summary.Count == 0 {
+		return types.Aggregate{}, app.storeMissingAggregate(ctx, req)
+	}
+
+	agg := types.Aggregate{
+		Avg:       summary.Mean,
+		Min:       summary.Min,
+		Max:       summary.Max,
+		Count:     summary.Count,
+		Timestamp: now,
+	}
+	applyStats(&agg, summary, req.requested)
+
+	entry := cachedAggregate{
+		Data:       agg,
+		FreshUntil: now.Add(aggregateTTL),
+	}
+	ttl := aggregateTTL + app.config.aggregateStaleTTL
+	if err := cache.Set(ctx, app.Cache, app.Codec, req.cacheKey, entry, ttl); err != nil {
+		app.logger.Error().Err(err).Str("cache_key", req.cacheKey).Str("ttl", ttl.String()).Msg("failed to store aggregate in cache")
+	}
+
+	return agg, nil
+}
@@ -0,0 +1,320 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+const (
+	defaultAlertHistoryLimit = 100
+	maxAlertHistoryLimit     = 1000
+)
+
+// alertRuleRequest is the body of rule creation and updates. Updates ignore the scope.
+type alertRuleRequest struct {
+	SensorID   *uuid.UUID             `json:"sensor_id"`
+	FieldID    *uuid.UUID             `json:"field_id"`
+	SensorType *types.SensorType      `json:"sensor_type"`
+	Comparator *types.AlertComparator `json:"comparator"`
+	Threshold  *float64               `json:"threshold"`
+	Hysteresis *float64               `json:"hysteresis"`
+	For        *types.Duration        `json:"for"`
+	Severity   *types.AlertSeverity   `json:"severity"`
+	Enabled    *bool                  `json:"enabled"`
+}
+
+// apply copies the conditions set in req onto rule and validates the result.
+func (req *alertRuleRequest) apply(rule *types.AlertRule) error {
+	if req.Comparator != nil {
+		rule.Comparator = *req.Comparator
+	}
+	if req.Threshold != nil {
+		rule.Threshold = *req.Threshold
+	}
+	if req.Hysteresis != nil {
+		rule.Hysteresis = *req.Hysteresis
+	}
+	if req.For != nil {
+		rule.For = *req.For
+	}
+	if req.Severity != nil {
+		rule.Severity = *req.Severity
+	}
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	switch {
+	case !rule.Comparator.Valid():
+		return errors.New("comparator must be one of gt, gte, lt or lte")
+	case !rule.Severity.Valid():
+		return errors.New("severity must be one of info, warning or critical")
+	case rule.Hysteresis < 0:
+		return errors.New("hysteresis must not be negative")
+	case rule.For < 0:
+		return errors.New("for must not be negative")
+	}
+	return nil
+}
+
+// alertRule loads a rule of the caller by its path ID. Rules of other users are reported
+// as not found.
+func (app *App) alertRule(w http.ResponseWriter, r *http.Request) (*types.AlertRule, bool) {
+	p, ok := app.principal(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid rule_id")
+		return nil, false
+	}
+
+	rule, err := app.Store.GetAlertRule(p.UserID, ruleID)
+	if err != nil {
+		if errors.Is(err, db.ErrAlertRuleNotFound) {
+			utils.ReplyNotFound(w, "alert rule not found")
+			return nil, false
+		}
+		utils.ReplyInternalServerError(w, err.Error())
+		return nil, false
+	}
+
+	return rule, true
+}
+
+func (app *App) listAlertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	p, ok := app.principal(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := app.Store.ListAlertRules(p.UserID)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": rules,
+	})
+}
+
+// createAlertRuleHandler adds a rule on exactly one of a sensor or a field. Sensor rules
+// watch the sensor's own type, field rules every sensor of sensor_type in the field.
+func (app *App) createAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	p, ok := app.principal(w, r)
+	if !ok {
+		return
+	}
+
+	var req alertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ReplyBadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.Threshold == nil {
+		utils.ReplyBadRequest(w, "missing threshold")
+		return
+	}
+
+	rule := types.AlertRule{
+		UserID:   p.UserID,
+		Severity: types.AlertSeverityWarning,
+		Enabled:  true,
+	}
+	if err := req.apply(&rule); err != nil {
+		utils.ReplyBadRequest(w, err.Error())
+		return
+	}
+
+	switch {
+	case req.SensorID != nil && req.FieldID == nil:
+		field, ok := app.authorizeSensor(w, r, *req.SensorID)
+		if !ok {
+			return
+		}
+
+		sType, err := app.Store.GetSensorType(r.Context(), *req.SensorID)
+		if err != nil {
+			utils.ReplyInternalServerError(w, err.Error())
+			return
+		}
+		if req.SensorType != nil && *req.SensorType != sType {
+			utils.ReplyBadRequest(w, "sensor_type does not match the sensor")
+			return
+		}
+
+		rule.FieldID = field.FieldID
+		rule.SensorID = req.SensorID
+		rule.SensorType = sType
+	case req.FieldID != nil && req.SensorID == nil:
+		if req.SensorType == nil || *req.SensorType < 0 || *req.SensorType > 2 {
+			utils.ReplyBadRequest(w, "field rules require a valid sensor_type")
+			return
+		}
+
+		if _, ok := app.authorizeField(w, r, *req.FieldID); !ok {
+			return
+		}
+
+		rule.FieldID = *req.FieldID
+		rule.SensorType = *req.SensorType
+	default:
+		utils.ReplyBadRequest(w, "exactly one of sensor_id or field_id is required")
+		return
+	}
+
+	created, err := app.Store.CreateAlertRule(rule)
+	if err != nil {
+		app.logger.Error().Err(err).Msg("failed to create alert rule")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	app.logger.Info().Str("rule_id", created.RuleID.String()).Str("field_id", created.FieldID.String()).Msg("alert rule created")
+
+	utils.ReplyJSON(w, http.StatusCreated, utils.Body{
+		"data": created,
+	})
+}
+
+func (app *App) getAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	rule, ok := app.alertRule(w, r)
+	if !ok {
+		return
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": rule,
+	})
+}
+
+// updateAlertRuleHandler changes the conditions of a rule. Its alert state is reset, so
+// an alert firing under the old conditions fires again only if the new ones hold.
+func (app *App) updateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	var req alertRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ReplyBadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.SensorID != nil || req.FieldID != nil || req.SensorType != nil {
+		utils.ReplyBadRequest(w, "the scope of a rule cannot change, create a new rule instead")
+		return
+	}
+
+	rule, ok := app.alertRule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := req.apply(rule); err != nil {
+		utils.ReplyBadRequest(w, err.Error())
+		return
+	}
+
+	updated, err := app.Store.UpdateAlertRule(*rule)
+	if err != nil {
+		app.logger.Error().Err(err).Str("rule_id", rule.RuleID.String()).Msg("failed to update alert rule")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": updated,
+	})
+}
+
+// deleteAlertRuleHandler removes a rule and its alert state. Its history is kept.
+func (app *App) deleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	rule, ok := app.alertRule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.Store.DeleteAlertRule(rule.UserID, rule.RuleID); err != nil {
+		app.logger.Error().Err(err).Str("rule_id", rule.RuleID.String()).Msg("failed to delete alert rule")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	app.logger.Info().Str("rule_id", rule.RuleID.String()).Msg("alert rule deleted")
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": map[string]any{
+			"rule_id": rule.RuleID,
+		},
+	})
+}
+
+// alertHistoryHandler lists the latest firing and resolved transitions of a rule.
+func (app *App) alertHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	limit := defaultAlertHistoryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxAlertHistoryLimit {
+			utils.ReplyBadRequest(w, "limit must be between 1 and "+strconv.Itoa(maxAlertHistoryLimit))
+			return
+		}
+	}
+
+	rule, ok := app.alertRule(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := app.Store.GetAlertHistory(rule.RuleID, limit)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+	if events == nil {
+		events = []types.AlertEvent{}
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": events,
+	})
+}
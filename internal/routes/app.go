@@ -4,40 +4,84 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/ntentasd/nostradamus-api/internal/arroyo"
+	"github.com/ntentasd/nostradamus-api/internal/auth"
 	"github.com/ntentasd/nostradamus-api/internal/cache"
 	"github.com/ntentasd/nostradamus-api/internal/db"
 	"github.com/ntentasd/nostradamus-api/internal/emqx"
+	"github.com/ntentasd/nostradamus-api/internal/stream"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
 )
 
 type Config struct {
 	driver string
+	// aggregateStaleTTL is how long an expired aggregate may still be served while
+	// it is refreshed in the background. Zero disables stale-while-revalidate.
+	aggregateStaleTTL time.Duration
+	// admins may call the /admin endpoints
+	admins map[uuid.UUID]bool
+	// readyAwaitsWarmup keeps /readyz failing until the first cache warm-up finished
+	readyAwaitsWarmup bool
 }
 
 type App struct {
 	Store *db.DB
 	Cache cache.Cache
+	Keys  *cache.Keys
+	Codec *cache.Codec
+	// Hub fans live readings out to stream clients
+	Hub *stream.Hub
 	*arroyo.ArroyoClient
 	*emqx.EmqxClient
+	authn  auth.Authenticator
 	logger zerolog.Logger
 	config *Config
+
+	aggregates *singleflight.Group
+	warmer     *Warmer
 }
 
 func NewConfig(driver string) *Config {
 	return &Config{
-		driver,
+		driver: driver,
+	}
+}
+
+func (c *Config) WithAdmins(userIDs []uuid.UUID) *Config {
+	c.admins = make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		c.admins[id] = true
 	}
+	return c
+}
+
+func (c *Config) WithReadyAwaitsWarmup(await bool) *Config {
+	c.readyAwaitsWarmup = await
+	return c
+}
+
+func (c *Config) WithAggregateStaleTTL(d time.Duration) *Config {
+	c.aggregateStaleTTL = d
+	return c
 }
 
-func New(store *db.DB, cache cache.Cache, ac *arroyo.ArroyoClient, ec *emqx.EmqxClient, logger zerolog.Logger, config *Config) *App {
+func New(store *db.DB, c cache.Cache, keys *cache.Keys, codec *cache.Codec, ac *arroyo.ArroyoClient, ec *emqx.EmqxClient, authn auth.Authenticator, logger zerolog.Logger, config *Config) *App {
 	return &App{
 		store,
-		cache,
+		c,
+		keys,
+		codec,
+		stream.NewHub(),
 		ac,
 		ec,
+		authn,
 		logger,
 		config,
+		&singleflight.Group{},
+		nil,
 	}
 }
 
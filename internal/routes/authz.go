@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/internal/auth"
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+// principal returns the authenticated caller. Routes behind auth.Middleware always have one.
+func (app *App) principal(w http.ResponseWriter, r *http.Request) (*auth.Principal, bool) {
+	p, ok := auth.FromContext(r.Context())
+	if !ok {
+		utils.ReplyUnauthorized(w, "unauthenticated")
+		return nil, false
+	}
+	return p, true
+}
+
+// ownedField returns the field if it exists and belongs to p, nil otherwise.
+func (app *App) ownedField(p *auth.Principal, fieldID uuid.UUID) (*types.Field, error) {
+	field, err := app.Store.GetFieldByID(fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if field == nil || field.UserID == nil || *field.UserID != p.UserID {
+		return nil, nil
+	}
+
+	return field, nil
+}
+
+// authorizeField loads a field and checks it belongs to the caller.
+// Fields owned by someone else are reported as not found so their existence is not leaked.
+func (app *App) authorizeField(w http.ResponseWriter, r *http.Request, fieldID uuid.UUID) (*types.Field, bool) {
+	p, ok := app.principal(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	field, err := app.ownedField(p, fieldID)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
+		return nil, false
+	}
+	if field == nil {
+		utils.ReplyNotFound(w, "field not found")
+		return nil, false
+	}
+
+	return field, true
+}
+
+// authorizeSensor checks the caller owns the field a sensor is registered under and returns that field.
+func (app *App) authorizeSensor(w http.ResponseWriter, r *http.Request, sensorID uuid.UUID) (*types.Field, bool) {
+	p, ok := app.principal(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	fieldID, err := app.sensorField(r.Context(), sensorID, bypassNegativeCache(r))
+	if err != nil {
+		if errors.Is(err, db.ErrSensorNotFound) {
+			utils.ReplyNotFound(w, "sensor not found")
+			return nil, false
+		}
+		utils.ReplyInternalServerError(w, err.Error())
+		return nil, false
+	}
+
+	field, err := app.ownedField(p, fieldID)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
+		return nil, false
+	}
+	if field == nil {
+		utils.ReplyNotFound(w, "sensor not found")
+		return nil, false
+	}
+
+	return field, true
+}
+
+// authorizeAdmin checks the caller is one of the configured admins.
+func (app *App) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	p, ok := app.principal(w, r)
+	if !ok {
+		return false
+	}
+
+	if !app.config.admins[p.UserID] {
+		utils.ReplyForbidden(w, "admin only")
+		return false
+	}
+
+	return true
+}
+
+// sensorField returns the field a sensor is registered under. Unknown sensors are
+// remembered in the negative cache, like in lookupSensorType.
+func (app *App) sensorField(ctx context.Context, sensorID uuid.UUID, bypass bool) (uuid.UUID, error) {
+	if !bypass && app.sensorKnownMissing(ctx, sensorID) {
+		return uuid.Nil, db.ErrSensorNotFound
+	}
+
+	fieldID, err := app.Store.GetSensorFieldID(sensorID)
+	if errors.Is(err, db.ErrSensorNotFound) {
+		app.rememberSensorMissing(ctx, sensorID)
+	}
+	return fieldID, err
+}
+
+// sensorOwned reports whether a sensor is registered under a field owned by p.
+// Unknown sensors are reported as not owned.
+func (app *App) sensorOwned(ctx context.Context, p *auth.Principal, sensorID uuid.UUID) (bool, error) {
+	fieldID, err := app.sensorField(ctx, sensorID, false)
+	if errors.Is(err, db.ErrSensorNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	field, err := app.ownedField(p, fieldID)
+	return field != nil, err
+}
@@ -0,0 +1,458 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ntentasd/nostradamus-api/internal/auth"
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/pb"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+// NewGRPCServer serves the SensorService on top of the same App as NewMux, along with
+// the standard health and reflection services.
+func NewGRPCServer(app *App) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(app.unaryAuthInterceptor),
+		grpc.StreamInterceptor(app.streamAuthInterceptor),
+	)
+
+	pb.RegisterSensorServiceServer(s, &sensorService{app: app})
+
+	hs := health.NewServer()
+	hs.SetServingStatus(pb.SensorService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+
+	reflection.Register(s)
+
+	return s
+}
+
+// authenticateRPC runs the HTTP authenticator chain against the call metadata, so gRPC
+// clients use the same Authorization and X-API-Key credentials as REST clients.
+func (app *App) authenticateRPC(ctx context.Context, method string) (context.Context, error) {
+	// Health checks and reflection stay public, like /healthz
+	if !strings.HasPrefix(method, "/"+pb.SensorService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, (&url.URL{Path: method}).String(), nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+
+	p, err := app.authn.Authenticate(r)
+	if err != nil {
+		app.logger.Warn().Err(err).Str("method", method).Msg("authentication failed")
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+func (app *App) unaryAuthInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := app.authenticateRPC(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (app *App) streamAuthInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := app.authenticateRPC(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ss, ctx})
+}
+
+// authenticatedStream carries the principal in the context of a server stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+type sensorService struct {
+	pb.UnimplementedSensorServiceServer
+	app *App
+}
+
+func (s *sensorService) principal(ctx context.Context) (*auth.Principal, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return p, nil
+}
+
+func (s *sensorService) ListFields(ctx context.Context, _ *pb.ListFieldsRequest) (*pb.ListFieldsResponse, error) {
+	p, err := s.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.app.Store.GetFieldsByUserID(p.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db error: %v", err)
+	}
+
+	res := &pb.ListFieldsResponse{Fields: make([]*pb.Field, 0, len(fields))}
+	for _, f := range fields {
+		res.Fields = append(res.Fields, fieldToProto(f))
+	}
+	return res, nil
+}
+
+func (s *sensorService) RegisterSensor(ctx context.Context, req *pb.RegisterSensorRequest) (*pb.RegisterSensorResponse, error) {
+	p, err := s.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldID, err := uuid.Parse(req.GetFieldId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid field_id")
+	}
+	if req.GetSensorName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing sensor_name")
+	}
+	sType, ok := sensorTypeFromProto(req.GetSensorType())
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid sensor type")
+	}
+
+	field, err := s.app.ownedField(p, fieldID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if field == nil {
+		return nil, status.Error(codes.NotFound, "field not found")
+	}
+
+	sensor, username, password, err := s.app.registerSensor(ctx, fieldID, req.GetSensorName(), sType)
+	if err != nil {
+		var dupErr *db.SensorAlreadyExistsError
+		if errors.As(err, &dupErr) {
+			return nil, status.Error(codes.AlreadyExists, dupErr.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.RegisterSensorResponse{
+		Sensor:   sensorToProto(*sensor),
+		MqttUser: username,
+		MqttPass: password,
+	}, nil
+}
+
+// ownedSensor parses a sensor ID, checks the caller owns the sensor and resolves its
+// stored type. A requested type other than the stored one is rejected.
+func (s *sensorService) ownedSensor(ctx context.Context, rawID string, requested pb.SensorType) (uuid.UUID, int, error) {
+	p, err := s.principal(ctx)
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+
+	sensorID, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, 0, status.Error(codes.InvalidArgument, "invalid sensor_id")
+	}
+
+	want := -1
+	if requested != pb.SensorType_SENSOR_TYPE_UNSPECIFIED {
+		sType, ok := sensorTypeFromProto(requested)
+		if !ok {
+			return uuid.Nil, 0, status.Error(codes.InvalidArgument, "invalid sensor type")
+		}
+		want = sType
+	}
+
+	owned, err := s.app.sensorOwned(ctx, p, sensorID)
+	if err != nil {
+		return uuid.Nil, 0, status.Error(codes.Internal, err.Error())
+	}
+	if !owned {
+		return uuid.Nil, 0, status.Error(codes.NotFound, "sensor not found")
+	}
+
+	sType, err := s.app.lookupSensorType(ctx, sensorID, false)
+	if errors.Is(err, db.ErrSensorNotFound) {
+		return uuid.Nil, 0, status.Error(codes.NotFound, "sensor not found")
+	}
+	if err != nil {
+		return uuid.Nil, 0, status.Error(codes.Internal, err.Error())
+	}
+
+	if want >= 0 && want != sType {
+		return uuid.Nil, 0, status.Error(codes.InvalidArgument, "sensor_type does not match the sensor")
+	}
+
+	return sensorID, sType, nil
+}
+
+func (s *sensorService) GetLatest(ctx context.Context, req *pb.GetLatestRequest) (*pb.GetLatestResponse, error) {
+	n := int(req.GetN())
+	if n == 0 {
+		n = defaultLatestN
+	}
+	if n < 1 || n > maxLatestN {
+		return nil, status.Errorf(codes.InvalidArgument, "n must be between 1 and %d", maxLatestN)
+	}
+
+	sensorID, sType, err := s.ownedSensor(ctx, req.GetSensorId(), req.GetSensorType())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.app.latest(ctx, sensorID, sType, n)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	res := &pb.GetLatestResponse{Entries: make([]*pb.Entry, 0, len(entries))}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, &pb.Entry{
+			Timestamp: timestamppb.New(e.Timestamp),
+			Value:     e.Value,
+		})
+	}
+	return res, nil
+}
+
+func (s *sensorService) GetAggregate(ctx context.Context, req *pb.GetAggregateRequest) (*pb.GetAggregateResponse, error) {
+	if req.GetWindow() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing window")
+	}
+	window := req.GetWindow().AsDuration()
+	if window <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid window")
+	}
+	if window > maxAggregateWindow {
+		return nil, status.Errorf(codes.InvalidArgument, "window must not exceed %s", maxAggregateWindow)
+	}
+
+	requested, err := parseStats(strings.Join(req.GetStats(), ","))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sensorID, sType, err := s.ownedSensor(ctx, req.GetSensorId(), req.GetSensorType())
+	if err != nil {
+		return nil, err
+	}
+
+	agg, err := s.app.aggregate(ctx, sensorID.String(), sType, window, requested, false)
+	switch {
+	case errors.Is(err, errNoReadings), errors.Is(err, errUnknownSensor):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		s.app.logger.Error().Err(err).Msg("failed to get readings from database")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetAggregateResponse{Aggregate: aggregateToProto(agg)}, nil
+}
+
+// streamTargets resolves the sensor or field of a StreamReadings call and checks the
+// caller may follow it.
+func (s *sensorService) streamTargets(ctx context.Context, req *pb.StreamReadingsRequest) ([]streamTarget, error) {
+	p, err := s.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target := req.GetTarget().(type) {
+	case *pb.StreamReadingsRequest_SensorId:
+		sensorID, sType, err := s.ownedSensor(ctx, target.SensorId, pb.SensorType_SENSOR_TYPE_UNSPECIFIED)
+		if err != nil {
+			return nil, err
+		}
+
+		return []streamTarget{{sensorID, sType}}, nil
+	case *pb.StreamReadingsRequest_FieldId:
+		fieldID, err := uuid.Parse(target.FieldId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid field_id")
+		}
+
+		field, err := s.app.ownedField(p, fieldID)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if field == nil {
+			return nil, status.Error(codes.NotFound, "field not found")
+		}
+
+		sensors, _, err := s.app.Store.GetSensorsByFieldID(fieldID)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		var targets []streamTarget
+		for _, sensor := range sensors {
+			if sensor.DecommissionedAt == nil {
+				targets = append(targets, streamTarget{sensor.SensorID, int(sensor.SensorType)})
+			}
+		}
+		return targets, nil
+	default:
+		return nil, status.Error(codes.InvalidArgument, "one of sensor_id or field_id is required")
+	}
+}
+
+func (s *sensorService) StreamReadings(req *pb.StreamReadingsRequest, stream grpc.ServerStreamingServer[pb.Reading]) error {
+	ctx := stream.Context()
+
+	targets, err := s.streamTargets(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	sensorIDs := make([]uuid.UUID, 0, len(targets))
+	for _, t := range targets {
+		sensorIDs = append(sensorIDs, t.sensorID)
+	}
+
+	// Subscribe before reading the backlog so nothing falls in between
+	sub := s.app.Hub.Subscribe(sensorIDs, sseBuffer)
+	defer s.app.Hub.Unsubscribe(sub)
+
+	metrics.StreamClients.WithLabelValues("grpc").Inc()
+	defer metrics.StreamClients.WithLabelValues("grpc").Dec()
+
+	// lastSent deduplicates readings delivered by both the backlog and the hub
+	lastSent := make(map[uuid.UUID]time.Time, len(targets))
+	send := func(reading types.Reading) error {
+		// The cache keeps millisecond precision, match it so replays compare equal
+		reading.Timestamp = reading.Timestamp.Truncate(time.Millisecond)
+		if !reading.Timestamp.After(lastSent[reading.SensorID]) {
+			return nil
+		}
+		lastSent[reading.SensorID] = reading.Timestamp
+
+		return stream.Send(readingToProto(reading))
+	}
+
+	if req.GetSince() != nil {
+		for _, reading := range s.app.backlog(ctx, targets, req.GetSince().AsTime()) {
+			if err := send(reading); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.Lagged():
+			return status.Error(codes.ResourceExhausted, "client too slow, resume with since")
+		case reading := <-sub.C:
+			if err := send(reading); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func sensorTypeFromProto(t pb.SensorType) (int, bool) {
+	switch t {
+	case pb.SensorType_SENSOR_TYPE_TEMPERATURE:
+		return int(types.SensorTypeTemperature), true
+	case pb.SensorType_SENSOR_TYPE_HUMIDITY:
+		return int(types.SensorTypeHumidity), true
+	case pb.SensorType_SENSOR_TYPE_PH_LEVEL:
+		return int(types.SensorTypePHLevel), true
+	default:
+		return 0, false
+	}
+}
+
+func sensorTypeToProto(t types.SensorType) pb.SensorType {
+	switch t {
+	case types.SensorTypeTemperature:
+		return pb.SensorType_SENSOR_TYPE_TEMPERATURE
+	case types.SensorTypeHumidity:
+		return pb.SensorType_SENSOR_TYPE_HUMIDITY
+	case types.SensorTypePHLevel:
+		return pb.SensorType_SENSOR_TYPE_PH_LEVEL
+	default:
+		return pb.SensorType_SENSOR_TYPE_UNSPECIFIED
+	}
+}
+
+func fieldToProto(f types.Field) *pb.Field {
+	res := &pb.Field{
+		FieldId:   f.FieldID.String(),
+		FieldName: f.FieldName,
+	}
+	if f.UserID != nil {
+		res.UserId = f.UserID.String()
+	}
+	return res
+}
+
+func sensorToProto(s types.Sensor) *pb.Sensor {
+	res := &pb.Sensor{
+		SensorId:   s.SensorID.String(),
+		SensorName: s.SensorName,
+		SensorType: sensorTypeToProto(s.SensorType),
+		FieldName:  s.FieldName,
+	}
+	if s.FieldID != nil {
+		res.FieldId = s.FieldID.String()
+	}
+	if s.DecommissionedAt != nil {
+		res.DecommissionedAt = timestamppb.New(*s.DecommissionedAt)
+	}
+	return res
+}
+
+func readingToProto(r types.Reading) *pb.Reading {
+	return &pb.Reading{
+		SensorId:   r.SensorID.String(),
+		SensorType: sensorTypeToProto(r.SensorType),
+		Timestamp:  timestamppb.New(r.Timestamp),
+		Value:      r.Value,
+	}
+}
+
+func aggregateToProto(a types.Aggregate) *pb.Aggregate {
+	return &pb.Aggregate{
+		Avg:         a.Avg,
+		Min:         a.Min,
+		Max:         a.Max,
+		Count:       int64(a.Count),
+		Median:      a.Median,
+		Stddev:      a.StdDev,
+		Variance:    a.Variance,
+		Percentiles: a.Percentiles,
+		Timestamp:   timestamppb.New(a.Timestamp),
+	}
+}
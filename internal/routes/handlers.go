@@ -2,6 +2,8 @@ package routes
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,8 +17,12 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 
+	"github.com/ntentasd/nostradamus-api/internal/auth"
+	"github.com/ntentasd/nostradamus-api/internal/cache"
 	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/emqx"
 	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/internal/saga"
 	"github.com/ntentasd/nostradamus-api/pkg/types"
 	"github.com/ntentasd/nostradamus-api/pkg/utils"
 )
@@ -39,73 +45,298 @@ FROM system.local
 		return
 	}
 
+	body := utils.Body{
+		"state": "ready",
+	}
+
+	// Requests fall back to Scylla without the cache, so it only degrades the service
 	if err := app.Cache.Ping(ctx); err != nil {
-		utils.ReplyUnavailable(w, fmt.Sprintf("%s unavailable", app.config.driver))
+		body["state"] = "degraded"
+		body["cache"] = fmt.Sprintf("%s unavailable", app.config.driver)
+	} else if b, ok := app.Cache.(*cache.Breaker); ok && b.State() != cache.BreakerClosed {
+		body["state"] = "degraded"
+		body["cache"] = fmt.Sprintf("%s circuit %s", app.config.driver, b.State())
+	}
+
+	if app.warmer != nil {
+		body["warmup"] = app.warmer.Progress()
+
+		if app.config.readyAwaitsWarmup && !app.warmer.Warmed() {
+			body["state"] = "warming_up"
+			utils.ReplyJSON(w, http.StatusServiceUnavailable, body)
+			return
+		}
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, body)
+}
+
+const (
+	defaultLatestN = 5
+	maxLatestN     = 500
+)
+
+// resolveSensorType returns the type registered in sensors_meta.sensors. The optional
+// sensor_type query param must match it. On failure it replies to the client and returns false.
+func (app *App) resolveSensorType(w http.ResponseWriter, r *http.Request, sensorID uuid.UUID) (int, bool) {
+	requested := -1
+	if sensorType := r.URL.Query().Get("sensor_type"); sensorType != "" {
+		sType, err := strconv.Atoi(sensorType)
+		if err != nil || sType < 0 || sType > 2 {
+			utils.ReplyBadRequest(w, "invalid sensor type")
+			return 0, false
+		}
+		requested = sType
+	}
+
+	sType, err := app.lookupSensorType(r.Context(), sensorID, bypassNegativeCache(r))
+	if err != nil {
+		if errors.Is(err, db.ErrSensorNotFound) {
+			utils.ReplyNotFound(w, "sensor not found")
+			return 0, false
+		}
+		utils.ReplyInternalServerError(w, err.Error())
+		return 0, false
+	}
+
+	if requested >= 0 && requested != sType {
+		utils.ReplyBadRequest(w, "sensor_type does not match the sensor")
+		return 0, false
+	}
+
+	return sType, true
+}
+
+// lookupSensorType resolves the type of a sensor through the negative cache.
+// Unknown sensors return db.ErrSensorNotFound.
+func (app *App) lookupSensorType(ctx context.Context, sensorID uuid.UUID, bypass bool) (int, error) {
+	if !bypass && app.sensorKnownMissing(ctx, sensorID) {
+		return 0, db.ErrSensorNotFound
+	}
+
+	resolved, err := app.Store.GetSensorType(ctx, sensorID)
+	if err != nil {
+		if errors.Is(err, db.ErrSensorNotFound) {
+			app.rememberSensorMissing(ctx, sensorID)
+			return 0, err
+		}
+		app.logger.Error().Err(err).Str("sensor_id", sensorID.String()).Msg("failed to resolve sensor type")
+		return 0, err
+	}
+
+	return int(resolved), nil
+}
+
+func (app *App) latestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	ctx := r.Context()
+
+	sensorIDStr := r.URL.Query().Get("sensor_id")
+	if sensorIDStr == "" {
+		utils.ReplyBadRequest(w, "missing sensor_id")
+		return
+	}
+
+	sensorID, err := uuid.Parse(sensorIDStr)
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	n := defaultLatestN
+	if nStr := r.URL.Query().Get("n"); nStr != "" {
+		n, err = strconv.Atoi(nStr)
+		if err != nil || n < 1 || n > maxLatestN {
+			utils.ReplyBadRequest(w, fmt.Sprintf("n must be between 1 and %d", maxLatestN))
+			return
+		}
+	}
+
+	if _, ok := app.authorizeSensor(w, r, sensorID); !ok {
+		return
+	}
+
+	sType, ok := app.resolveSensorType(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	res, err := app.latest(ctx, sensorID, sType, n)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
 		return
 	}
 
 	utils.ReplyJSON(w, http.StatusOK, utils.Body{
-		"state": "ready",
+		"data": res,
 	})
 }
 
-func (app *App) latestHandler(w http.ResponseWriter, r *http.Request) {
-	year, month, day := time.Now().Date()
-	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
-	res, err := app.Cache.FetchLast(
-		fmt.Sprintf(
-			"%s:%s:%s",
-			"sensor",
-			"550e8400-e29b-41d4-a716-446655440000",
-			today.Format("2006-01-02"),
-		),
-		5)
-	if err != nil {
-		utils.ReplyJSON(
-			w,
-			http.StatusInternalServerError,
-			map[string]any{
-				"error": err.Error(),
-			},
-		)
+// latest returns the n most recent readings of a sensor from the cache, refilling it
+// from the database when it holds fewer.
+func (app *App) latest(ctx context.Context, sensorID uuid.UUID, sType int, n int) ([]types.Entry, error) {
+	cacheKey := app.Keys.Latest(sensorID.String(), sType)
+
+	// A failing cache is treated like a cold one, the database has every reading
+	res, err := app.Cache.FetchLast(ctx, cacheKey, n)
+	if err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+		app.logger.Warn().Err(err).Str("cache_key", cacheKey).Msg("failed to fetch latest values from cache")
+	}
+
+	// Less than n, cache is stale
+	if err != nil || len(res) < n {
+		res, err = app.Store.GetLastValues(ctx, sensorID.String(), sType, n)
+		if err != nil {
+			app.logger.Error().Err(err).Str("sensor_id", sensorID.String()).Msg("failed to get latest values from database")
+			return nil, err
+		}
+		if err := app.Cache.StoreMany(ctx, cacheKey, res, cache.LatestTTL); err != nil && !errors.Is(err, cache.ErrCircuitOpen) {
+			app.logger.Warn().Err(err).Str("cache_key", cacheKey).Msg("failed to store entries in cache")
+		}
+	}
+
+	return res, nil
+}
+
+const (
+	defaultReadingsLimit = 500
+	maxReadingsLimit     = 5000
+	maxReadingsWindow    = 31 * 24 * time.Hour
+)
+
+func (app *App) readingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
 		return
 	}
 
-	// Less than 5, cache is stale
-	if len(res) < 5 {
-		res, err = app.Store.GetLast5Values(
-			"550e8400-e29b-41d4-a716-446655440000",
-			today.Format("2006-01-02"),
-		)
+	ctx, span := otel.Tracer("nostradamus-api").Start(r.Context(), "HTTP /readings")
+	defer span.End()
+
+	start := time.Now()
+
+	defer func() {
+		metrics.HttpRequestLatencySeconds.WithLabelValues("GET").Observe(time.Since(start).Seconds())
+	}()
+
+	q := r.URL.Query()
+
+	sensorIDStr := q.Get("sensor_id")
+	if sensorIDStr == "" {
+		utils.ReplyBadRequest(w, "missing sensor_id")
+		return
+	}
+
+	sensorID, err := uuid.Parse(sensorIDStr)
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	to := time.Now().UTC()
+	if toStr := q.Get("to"); toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid to, expected RFC3339")
+			return
+		}
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if fromStr := q.Get("from"); fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid from, expected RFC3339")
+			return
+		}
+	}
+
+	from, to = from.UTC(), to.UTC()
+	if !from.Before(to) {
+		utils.ReplyBadRequest(w, "from must be before to")
+		return
+	}
+	if to.Sub(from) > maxReadingsWindow {
+		utils.ReplyBadRequest(w, fmt.Sprintf("window must not exceed %s", maxReadingsWindow))
+		return
+	}
+
+	limit := defaultReadingsLimit
+	if limitStr := q.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			utils.ReplyBadRequest(w, "invalid limit")
+			return
+		}
+		limit = min(limit, maxReadingsLimit)
+	}
+
+	var ascending bool
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		ascending = true
+	default:
+		utils.ReplyBadRequest(w, "order must be asc or desc")
+		return
+	}
+
+	var cursor *db.ReadingsCursor
+	if token := q.Get("cursor"); token != "" {
+		cursor, err = db.DecodeReadingsCursor(token)
 		if err != nil {
-			utils.ReplyJSON(
-				w,
-				http.StatusInternalServerError,
-				map[string]any{
-					"error": err.Error(),
-				},
-			)
+			utils.ReplyBadRequest(w, err.Error())
 			return
 		}
-		// TODO: Create pipelined function
-		for _, entry := range res {
-			app.Cache.Store(
-				fmt.Sprintf(
-					"%s:%s:%s",
-					"sensor",
-					"550e8400-e29b-41d4-a716-446655440000",
-					today,
-				),
-				entry,
-			)
+	}
+
+	if _, ok := app.authorizeSensor(w, r, sensorID); !ok {
+		return
+	}
+
+	sType, ok := app.resolveSensorType(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("sensor.id", sensorID.String()),
+		attribute.Int("sensor.type", sType),
+		attribute.Int("page.limit", limit),
+	)
+
+	page, err := app.Store.GetReadingsPage(ctx, sensorID.String(), sType, from, to, limit, ascending, cursor)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidCursor) {
+			utils.ReplyBadRequest(w, err.Error())
+			return
 		}
+		app.logger.Error().Err(err).Str("sensor_id", sensorIDStr).Msg("failed to get readings page from database")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	var next any
+	if page.Next != nil {
+		next = page.Next.Encode()
 	}
 
 	utils.ReplyJSON(w, http.StatusOK, utils.Body{
-		"data": res,
+		"data":        page.Entries,
+		"next_cursor": next,
 	})
+	span.SetStatus(codes.Ok, "")
 }
 
+// maxAggregateWindow bounds the day partitions a single aggregate fans out over.
+const maxAggregateWindow = 366 * 24 * time.Hour
+
 func (app *App) aggregateHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("nostradamus-api").Start(r.Context(), "HTTP /aggregate")
 	defer span.End()
@@ -116,17 +347,13 @@ func (app *App) aggregateHandler(w http.ResponseWriter, r *http.Request) {
 		metrics.HttpRequestLatencySeconds.WithLabelValues("GET").Observe(time.Since(start).Seconds())
 	}()
 
-	sensorID := r.URL.Query().Get("sensor_id")
-	sensorType := r.URL.Query().Get("sensor_type")
+	sensorIDStr := r.URL.Query().Get("sensor_id")
 	windowStr := r.URL.Query().Get("window")
 
 	missing := []string{}
-	if sensorID == "" {
+	if sensorIDStr == "" {
 		missing = append(missing, "sensor_id")
 	}
-	if sensorType == "" {
-		missing = append(missing, "sensor_type")
-	}
 	if windowStr == "" {
 		missing = append(missing, "window_str")
 	}
@@ -136,76 +363,53 @@ func (app *App) aggregateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sType, err := strconv.Atoi(sensorType)
-	if err != nil || sType < 0 || sType > 2 {
-		app.logger.Error().Err(err).Int("sensor_type_num", sType).Msg("invalid sensor type")
-		utils.ReplyBadRequest(w, "invalid sensor type")
+	sensorID, err := uuid.Parse(sensorIDStr)
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
 		return
 	}
 
 	dur, err := time.ParseDuration(windowStr)
-	if err != nil {
-		app.logger.Error().Err(err).Str("duration", dur.String()).Msg("invalid window")
+	if err != nil || dur <= 0 {
+		app.logger.Error().Err(err).Str("window", windowStr).Msg("invalid window")
 		utils.ReplyBadRequest(w, "invalid window")
 		return
 	}
-
-	now := time.Now().UTC()
-	today := now.Format("2006-01-02")
-	cacheKey := fmt.Sprintf("agg:%s:%s:%s", sensorID, today, windowStr)
-
-	cached, err := app.Cache.FetchAggregate(ctx, cacheKey)
-	if err == nil && cached != nil {
-		var agg types.Aggregate
-		if err = json.Unmarshal(cached, &agg); err == nil {
-			utils.ReplyJSON(w, http.StatusOK, utils.Body{
-				"data": agg,
-			})
-			span.SetStatus(codes.Ok, "")
-			return
-		}
-
-		app.logger.Warn().Err(err).Str("cache_key", cacheKey).Str("cache_value", string(cached)).Msg("invalid cache entry")
-		span.SetAttributes(attribute.String("cache.result", "corrupt"))
-		span.RecordError(err)
+	if dur > maxAggregateWindow {
+		utils.ReplyBadRequest(w, fmt.Sprintf("window must not exceed %s", maxAggregateWindow))
+		return
 	}
 
-	readings, err := app.Store.GetReadings(ctx, sensorID, sType, now.Add(-dur), now)
+	requested, err := parseStats(r.URL.Query().Get("stats"))
 	if err != nil {
-		app.logger.Error().Err(err).Msg("failed to get readings from database")
-		utils.ReplyInternalServerError(w, err.Error())
+		app.logger.Error().Err(err).Msg("invalid stats")
+		utils.ReplyBadRequest(w, err.Error())
 		return
 	}
 
-	if len(readings) == 0 {
-		app.logger.Warn().Msg("no readings found")
-		utils.ReplyNotFound(w, "no readings found")
+	if _, ok := app.authorizeSensor(w, r, sensorID); !ok {
 		return
 	}
 
-	sum, min, max := 0.0, readings[0], readings[0]
-	for _, v := range readings {
-		sum += v
-		if v < min {
-			min = v
-		}
-		if v > max {
-			max = v
-		}
+	sType, ok := app.resolveSensorType(w, r, sensorID)
+	if !ok {
+		return
 	}
 
-	agg := types.Aggregate{
-		Avg:       sum / float64(len(readings)),
-		Min:       min,
-		Max:       max,
-		Count:     len(readings),
-		Timestamp: now,
+	agg, err := app.aggregate(ctx, sensorID.String(), sType, dur, requested, bypassNegativeCache(r))
+	if errors.Is(err, errNoReadings) {
+		app.logger.Warn().Msg("no readings found")
+		replyAggregateMissing(w, reasonNoReadings)
+		return
+	}
+	if errors.Is(err, errUnknownSensor) {
+		replyAggregateMissing(w, reasonUnknownSensor)
+		return
 	}
-
-	ttl := time.Minute * 5
-	err = app.Cache.StoreAggregate(ctx, cacheKey, agg, ttl)
 	if err != nil {
-		app.logger.Error().Err(err).Str("cache_key", cacheKey).Str("ttl", ttl.String()).Msg("failed to store aggregate in cache")
+		app.logger.Error().Err(err).Msg("failed to get readings from database")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
 	}
 
 	utils.ReplyJSON(w, http.StatusOK, utils.Body{
@@ -220,16 +424,22 @@ func (app *App) fieldsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDstr := r.URL.Query().Get("user_id")
-	if userIDstr == "" {
-		http.Error(w, "missing user_id", http.StatusBadRequest)
+	p, ok := app.principal(w, r)
+	if !ok {
 		return
 	}
 
-	userID, err := uuid.Parse(userIDstr)
-	if err != nil {
-		http.Error(w, "invalid user_id", http.StatusBadRequest)
-		return
+	userID := p.UserID
+	if userIDstr := r.URL.Query().Get("user_id"); userIDstr != "" {
+		requested, err := uuid.Parse(userIDstr)
+		if err != nil {
+			http.Error(w, "invalid user_id", http.StatusBadRequest)
+			return
+		}
+		if requested != userID {
+			utils.ReplyForbidden(w, "cannot list fields of another user")
+			return
+		}
 	}
 
 	fields, err := app.Store.GetFieldsByUserID(userID)
@@ -269,7 +479,12 @@ func (app *App) getFieldByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	field, err := app.Store.GetFieldByID(fieldID)
+	p, ok := app.principal(w, r)
+	if !ok {
+		return
+	}
+
+	field, err := app.ownedField(p, fieldID)
 	if err != nil {
 		utils.ReplyJSON(w, http.StatusInternalServerError, utils.Body{
 			"error": err.Error(),
@@ -307,14 +522,26 @@ func (app *App) registerFieldHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userUUID, err := uuid.Parse(req.UserID)
-	if err != nil {
-		utils.ReplyJSON(w, http.StatusBadRequest, utils.Body{
-			"error": "invalid user_id",
-		})
+	p, ok := app.principal(w, r)
+	if !ok {
 		return
 	}
 
+	userUUID := p.UserID
+	if req.UserID != "" {
+		requested, err := uuid.Parse(req.UserID)
+		if err != nil {
+			utils.ReplyJSON(w, http.StatusBadRequest, utils.Body{
+				"error": "invalid user_id",
+			})
+			return
+		}
+		if requested != userUUID {
+			utils.ReplyForbidden(w, "cannot register fields for another user")
+			return
+		}
+	}
+
 	field, err := app.Store.RegisterField(userUUID, req.FieldName)
 	if err != nil {
 		var dupErr *db.FieldAlreadyExistsError
@@ -336,6 +563,112 @@ func (app *App) registerFieldHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (app *App) renameFieldHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	fieldID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid field_id")
+		return
+	}
+
+	var req struct {
+		FieldName string `json:"field_name"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.FieldName == "" {
+		utils.ReplyBadRequest(w, "invalid request body")
+		return
+	}
+
+	field, ok := app.authorizeField(w, r, fieldID)
+	if !ok {
+		return
+	}
+
+	renamed, err := app.Store.RenameField(*field.UserID, fieldID, req.FieldName)
+	if err != nil {
+		var dupErr *db.FieldAlreadyExistsError
+		if errors.As(err, &dupErr) {
+			utils.ReplyBadRequest(w, dupErr.Error())
+			return
+		}
+		if errors.Is(err, db.ErrFieldNotFound) {
+			utils.ReplyNotFound(w, "field not found")
+			return
+		}
+
+		app.logger.Error().Err(err).Str("field_id", fieldID.String()).Msg("failed to rename field")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": renamed,
+	})
+}
+
+// deleteFieldHandler removes a field with all of its sensors and revokes their MQTT users.
+// With ?purge=true the sensors' readings are deleted as well.
+func (app *App) deleteFieldHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	fieldID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid field_id")
+		return
+	}
+
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+
+	field, ok := app.authorizeField(w, r, fieldID)
+	if !ok {
+		return
+	}
+
+	deleted, err := app.Store.DeleteField(*field.UserID, fieldID)
+	if err != nil {
+		app.logger.Error().Err(err).Str("field_id", fieldID.String()).Msg("failed to delete field")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	// The meta rows are gone at this point, cleanup failures are reported but not fatal
+	var failures []string
+	for _, s := range deleted {
+		if s.MqttUser != "" {
+			if err := app.DeleteUser(s.MqttUser); err != nil {
+				app.logger.Warn().Err(err).Str("username", s.MqttUser).Msg("failed to delete EMQX user")
+				failures = append(failures, fmt.Sprintf("revoke %s: %v", s.MqttUser, err))
+			}
+		}
+
+		if purge && s.SensorType >= 0 {
+			if err := app.Store.PurgeSensorData(r.Context(), s.SensorID, int(s.SensorType), s.RegisteredAt); err != nil {
+				app.logger.Warn().Err(err).Str("sensor_id", s.SensorID.String()).Msg("failed to purge sensor data")
+				failures = append(failures, fmt.Sprintf("purge %s: %v", s.SensorID, err))
+			}
+		}
+	}
+
+	app.logger.Info().Str("field_id", fieldID.String()).Int("sensors", len(deleted)).Bool("purge", purge).Msg("field deleted")
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": map[string]any{
+			"field_id":        fieldID,
+			"deleted_sensors": len(deleted),
+			"purged":          purge,
+			"cleanup_errors":  failures,
+		},
+	})
+}
+
 func (app *App) sensorsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		utils.ReplyMethodNotAllowed(w)
@@ -354,6 +687,10 @@ func (app *App) sensorsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := app.authorizeField(w, r, fieldID); !ok {
+		return
+	}
+
 	sensors, field, err := app.Store.GetSensorsByFieldID(fieldID)
 	if err != nil {
 		http.Error(
@@ -397,11 +734,11 @@ func (app *App) registerSensorHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sensor, err := app.Store.RegisterSensor(
-		fieldUUID,
-		req.SensorName,
-		req.SensorType,
-	)
+	if _, ok := app.authorizeField(w, r, fieldUUID); !ok {
+		return
+	}
+
+	sensor, username, password, err := app.registerSensor(r.Context(), fieldUUID, req.SensorName, req.SensorType)
 	if err != nil {
 		var dupErr *db.SensorAlreadyExistsError
 		if errors.As(err, &dupErr) {
@@ -410,40 +747,243 @@ func (app *App) registerSensorHandler(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+
 		utils.ReplyJSON(w, http.StatusInternalServerError, utils.Body{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	username := fmt.Sprintf(
-		"%s_%s",
-		sensor.SensorID.String()[:8],
-		req.SensorName,
+	utils.ReplyJSON(w, http.StatusCreated, utils.Body{
+		"data": map[string]any{
+			"sensor":    sensor,
+			"mqtt_user": username,
+			"mqtt_pass": password,
+		},
+	})
+}
+
+// registerSensor registers a sensor together with its EMQX user and stored credentials,
+// rolling all of them back when one fails, and returns its MQTT credentials.
+func (app *App) registerSensor(ctx context.Context, fieldUUID uuid.UUID, sensorName string, sensorType int) (*types.Sensor, string, string, error) {
+	var (
+		sensor   *types.Sensor
+		username string
+		password = generateMqttPassword()
 	)
-	password := uuid.NewString()[:12]
 
-	if _, err = app.CreateUser(username, password, false); err != nil {
-		app.logger.Warn().Err(err).Str("sensor", username).Msg("failed to create EMQX user")
-	} else {
-		app.logger.Info().Str("username", username).Str("sensor_name", sensor.SensorName).Msg("EMQX user created successfully")
+	// Meta rows, EMQX user and stored credentials succeed together or are rolled back
+	err := saga.Run(ctx, app.logger,
+		saga.Step{
+			Name: "register sensor",
+			Do: func(context.Context) error {
+				var err error
+				sensor, err = app.Store.RegisterSensor(fieldUUID, sensorName, sensorType)
+				if err == nil {
+					username = emqx.SensorUsername(sensor.SensorID, sensor.SensorName)
+				}
+				return err
+			},
+			Compensate: func(context.Context) error {
+				return app.Store.DeleteSensor(fieldUUID, sensor.SensorID)
+			},
+		},
+		saga.Step{
+			Name: "create EMQX user",
+			Do: func(context.Context) error {
+				_, err := app.CreateUser(username, password, false)
+				return err
+			},
+			Compensate: func(context.Context) error {
+				return app.DeleteUser(username)
+			},
+		},
+		saga.Step{
+			Name: "store MQTT credentials",
+			Do: func(context.Context) error {
+				return app.Store.StoreSensorCredentials(
+					gocql.UUID(fieldUUID),
+					gocql.UUID(sensor.SensorID),
+					username,
+					password,
+				)
+			},
+		},
+	)
+	if err != nil {
+		var dupErr *db.SensorAlreadyExistsError
+		if !errors.As(err, &dupErr) {
+			app.logger.Error().Err(err).Str("field_id", fieldUUID.String()).Str("sensor_name", sensorName).Msg("sensor registration rolled back")
+		}
+		return nil, "", "", err
 	}
 
-	err = app.Store.StoreSensorCredentials(
-		gocql.UUID(fieldUUID),
-		gocql.UUID(sensor.SensorID),
-		username,
-		password,
-	)
+	app.logger.Info().Str("username", username).Str("sensor_name", sensor.SensorName).Msg("sensor registered")
+	return sensor, username, password, nil
+}
+
+// replySensorError maps the sensor lifecycle errors of the db package to responses.
+func (app *App) replySensorError(w http.ResponseWriter, sensorID uuid.UUID, err error) {
+	var dupErr *db.SensorAlreadyExistsError
+	switch {
+	case errors.As(err, &dupErr):
+		utils.ReplyBadRequest(w, dupErr.Error())
+	case errors.Is(err, db.ErrSensorNotFound):
+		utils.ReplyNotFound(w, "sensor not found")
+	case errors.Is(err, db.ErrSensorDecommissioned), errors.Is(err, db.ErrSensorHasReadings):
+		utils.ReplyJSON(w, http.StatusConflict, utils.Body{
+			"error": err.Error(),
+		})
+	default:
+		app.logger.Error().Err(err).Str("sensor_id", sensorID.String()).Msg("sensor update failed")
+		utils.ReplyInternalServerError(w, err.Error())
+	}
+}
+
+func (app *App) updateSensorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	sensorID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		app.logger.Warn().Err(err).Str("sensor_id", sensor.SensorID.String()).Msg("failed to store MQTT credentials")
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
 	}
 
-	utils.ReplyJSON(w, http.StatusCreated, utils.Body{
+	var req struct {
+		SensorName *string `json:"sensor_name"`
+		SensorType *int    `json:"sensor_type"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ReplyBadRequest(w, "invalid request body")
+		return
+	}
+
+	if req.SensorName == nil && req.SensorType == nil {
+		utils.ReplyBadRequest(w, "nothing to update")
+		return
+	}
+	if req.SensorName != nil && *req.SensorName == "" {
+		utils.ReplyBadRequest(w, "invalid sensor_name")
+		return
+	}
+	if req.SensorType != nil && (*req.SensorType < 0 || *req.SensorType > 2) {
+		utils.ReplyBadRequest(w, "invalid sensor type")
+		return
+	}
+
+	field, ok := app.authorizeSensor(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	sensor, err := app.Store.UpdateSensor(field.FieldID, sensorID, req.SensorName, req.SensorType)
+	if err != nil {
+		app.replySensorError(w, sensorID, err)
+		return
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": sensor,
+	})
+}
+
+func (app *App) moveSensorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	sensorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	var req struct {
+		FieldID string `json:"field_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ReplyBadRequest(w, "invalid request body")
+		return
+	}
+
+	toFieldID, err := uuid.Parse(req.FieldID)
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid field_id")
+		return
+	}
+
+	from, ok := app.authorizeSensor(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	if from.FieldID == toFieldID {
+		utils.ReplyBadRequest(w, "sensor already belongs to field")
+		return
+	}
+
+	if _, ok := app.authorizeField(w, r, toFieldID); !ok {
+		return
+	}
+
+	sensor, err := app.Store.MoveSensor(from.FieldID, toFieldID, sensorID)
+	if err != nil {
+		app.replySensorError(w, sensorID, err)
+		return
+	}
+
+	app.logger.Info().Str("sensor_id", sensorID.String()).Str("from_field", from.FieldID.String()).Str("to_field", toFieldID.String()).Msg("sensor moved")
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": sensor,
+	})
+}
+
+// decommissionSensorHandler retires a sensor and removes its EMQX user. Its readings stay queryable.
+func (app *App) decommissionSensorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	sensorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	field, ok := app.authorizeSensor(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	sensor, mqttUser, err := app.Store.DecommissionSensor(field.FieldID, sensorID)
+	if err != nil {
+		app.replySensorError(w, sensorID, err)
+		return
+	}
+
+	revoked := false
+	if mqttUser != "" {
+		if err := app.DeleteUser(mqttUser); err != nil {
+			app.logger.Warn().Err(err).Str("username", mqttUser).Msg("failed to delete EMQX user")
+		} else {
+			revoked = true
+		}
+	}
+
+	app.logger.Info().Str("sensor_id", sensorID.String()).Bool("mqtt_revoked", revoked).Msg("sensor decommissioned")
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
 		"data": map[string]any{
-			"sensor":    sensor,
-			"mqtt_user": username,
-			"mqtt_pass": password,
+			"sensor":       sensor,
+			"mqtt_revoked": revoked,
 		},
 	})
 }
@@ -473,6 +1013,10 @@ func (app *App) getSensorCredentialsHandler(
 		return
 	}
 
+	if _, ok := app.authorizeSensor(w, r, sensorID); !ok {
+		return
+	}
+
 	creds, err := app.Store.GetSensorCredentials(sensorID)
 	if err != nil {
 		if errors.Is(err, db.ErrSensorNotFound) {
@@ -492,3 +1036,171 @@ func (app *App) getSensorCredentialsHandler(
 		"data": creds,
 	})
 }
+
+// generateMqttPassword returns a random URL-safe password for an MQTT user.
+func generateMqttPassword() string {
+	b := make([]byte, 18)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// auditCredentials records a credential change made by the caller. Failures are logged only,
+// the change itself has already been applied.
+func (app *App) auditCredentials(r *http.Request, sensorID uuid.UUID, action db.CredentialAction, mqttUser string) {
+	entry := db.CredentialAuditEntry{
+		SensorID:  sensorID,
+		Action:    action,
+		MqttUser:  mqttUser,
+		Timestamp: time.Now().UTC(),
+	}
+	if p, ok := auth.FromContext(r.Context()); ok {
+		entry.ActorID = p.UserID
+		entry.AuthMethod = string(p.Method)
+	}
+
+	if err := app.Store.RecordCredentialAudit(entry); err != nil {
+		app.logger.Error().Err(err).Str("sensor_id", sensorID.String()).Str("action", string(action)).Msg("failed to record credential audit")
+		return
+	}
+
+	app.logger.Info().
+		Str("sensor_id", sensorID.String()).
+		Str("action", string(action)).
+		Str("actor_id", entry.ActorID.String()).
+		Str("username", mqttUser).
+		Msg("sensor credentials changed")
+}
+
+// rotateSensorCredentialsHandler issues a new MQTT password for a sensor, updating EMQX first
+// and then the stored copy. When storing fails EMQX gets the old password back, so the two
+// never disagree. The new password is only returned in this response.
+func (app *App) rotateSensorCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	sensorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	field, ok := app.authorizeSensor(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	creds, err := app.Store.GetActiveSensorCredentials(field.FieldID, sensorID)
+	if err != nil {
+		app.replySensorError(w, sensorID, err)
+		return
+	}
+
+	if creds.MqttUser == "" {
+		utils.ReplyNotFound(w, "sensor has no MQTT credentials")
+		return
+	}
+
+	password := generateMqttPassword()
+
+	// EMQX and the stored credentials change together, or EMQX gets the old password back
+	err = saga.Run(r.Context(), app.logger,
+		saga.Step{
+			Name: "update EMQX password",
+			Do: func(context.Context) error {
+				return app.UpdatePassword(creds.MqttUser, password)
+			},
+			Compensate: func(context.Context) error {
+				// Without a stored password there is nothing to restore, and an empty one
+				// would lock the sensor out; the failure is reported instead
+				if creds.MqttPass == "" {
+					return errors.New("no previous password stored, EMQX keeps the new one")
+				}
+				return app.UpdatePassword(creds.MqttUser, creds.MqttPass)
+			},
+		},
+		saga.Step{
+			Name: "store MQTT credentials",
+			Do: func(context.Context) error {
+				return app.Store.StoreSensorCredentials(
+					gocql.UUID(field.FieldID),
+					gocql.UUID(sensorID),
+					creds.MqttUser,
+					password,
+				)
+			},
+		},
+	)
+	if err != nil {
+		app.logger.Error().Err(err).Str("sensor_id", sensorID.String()).Msg("MQTT credential rotation rolled back")
+
+		var stepErr *saga.StepError
+		if errors.As(err, &stepErr) && stepErr.Step == "update EMQX password" {
+			utils.ReplyJSON(w, http.StatusBadGateway, utils.Body{
+				"error": "failed to update MQTT broker: " + stepErr.Err.Error(),
+			})
+			return
+		}
+		utils.ReplyInternalServerError(w, "failed to rotate MQTT credentials: "+err.Error())
+		return
+	}
+
+	app.auditCredentials(r, sensorID, db.CredentialActionRotate, creds.MqttUser)
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": map[string]any{
+			"mqtt_user": creds.MqttUser,
+			"mqtt_pass": password,
+		},
+	})
+}
+
+// revokeSensorCredentialsHandler removes the sensor's EMQX user and its stored credentials.
+func (app *App) revokeSensorCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	sensorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	field, ok := app.authorizeSensor(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	creds, err := app.Store.GetActiveSensorCredentials(field.FieldID, sensorID)
+	if err != nil {
+		app.replySensorError(w, sensorID, err)
+		return
+	}
+
+	if creds.MqttUser == "" {
+		utils.ReplyNotFound(w, "sensor has no MQTT credentials")
+		return
+	}
+
+	if err := app.DeleteUser(creds.MqttUser); err != nil {
+		app.logger.Error().Err(err).Str("username", creds.MqttUser).Msg("failed to delete EMQX user")
+		utils.ReplyJSON(w, http.StatusBadGateway, utils.Body{
+			"error": "failed to update MQTT broker: " + err.Error(),
+		})
+		return
+	}
+
+	if err := app.Store.ClearSensorCredentials(field.FieldID, sensorID); err != nil {
+		app.logger.Error().Err(err).Str("sensor_id", sensorID.String()).Msg("failed to clear MQTT credentials")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	app.auditCredentials(r, sensorID, db.CredentialActionRevoke, creds.MqttUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
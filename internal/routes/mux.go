@@ -4,6 +4,7 @@ package routes
 import (
 	"net/http"
 
+	"github.com/ntentasd/nostradamus-api/internal/auth"
 	"github.com/ntentasd/nostradamus-api/pkg/utils"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -18,12 +19,18 @@ func NewMux(app *App) http.Handler {
 	// metrics
 	mux.Handle("/metrics", promhttp.Handler())
 
-	// get 5 latest values
-	mux.HandleFunc("/latest", app.latestHandler)
-	mux.HandleFunc("/aggregate", app.aggregateHandler)
+	// everything below requires an authenticated caller
+	api := http.NewServeMux()
+	mux.Handle("/", auth.Middleware(app.authn, app.logger)(api))
+
+	// get N latest values
+	api.HandleFunc("/latest", app.latestHandler)
+	api.HandleFunc("/aggregate", app.aggregateHandler)
+	api.HandleFunc("/aggregate/series", app.aggregateSeriesHandler)
+	api.HandleFunc("/readings", app.readingsHandler)
 
 	// get fields & sensors
-	mux.HandleFunc("/fields", func(w http.ResponseWriter, r *http.Request) {
+	api.HandleFunc("/fields", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			app.fieldsHandler(w, r)
@@ -33,9 +40,19 @@ func NewMux(app *App) http.Handler {
 			utils.ReplyMethodNotAllowed(w)
 		}
 	})
-	mux.HandleFunc("/field", app.getFieldByIDHandler)
+	api.HandleFunc("/fields/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			app.renameFieldHandler(w, r)
+		case http.MethodDelete:
+			app.deleteFieldHandler(w, r)
+		default:
+			utils.ReplyMethodNotAllowed(w)
+		}
+	})
+	api.HandleFunc("/field", app.getFieldByIDHandler)
 
-	mux.HandleFunc("/sensors", func(w http.ResponseWriter, r *http.Request) {
+	api.HandleFunc("/sensors", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			app.sensorsHandler(w, r)
@@ -45,13 +62,83 @@ func NewMux(app *App) http.Handler {
 			utils.ReplyMethodNotAllowed(w)
 		}
 	})
-	mux.HandleFunc("/sensors/credentials", app.getSensorCredentialsHandler)
+	api.HandleFunc("/sensors/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			app.updateSensorHandler(w, r)
+		case http.MethodDelete:
+			app.decommissionSensorHandler(w, r)
+		default:
+			utils.ReplyMethodNotAllowed(w)
+		}
+	})
+	api.HandleFunc("/sensors/{id}/move", app.moveSensorHandler)
+	api.HandleFunc("/sensors/credentials", app.getSensorCredentialsHandler)
+	api.HandleFunc("/sensors/{id}/credentials", app.revokeSensorCredentialsHandler)
+	api.HandleFunc("/sensors/{id}/credentials/rotate", app.rotateSensorCredentialsHandler)
+
+	// live routes
+	api.HandleFunc("/stream/readings", app.streamReadingsHandler)
+	api.HandleFunc("/ws", app.wsHandler)
+
+	// alert routes
+	api.HandleFunc("/alerts/rules", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			app.listAlertRulesHandler(w, r)
+		case http.MethodPost:
+			app.createAlertRuleHandler(w, r)
+		default:
+			utils.ReplyMethodNotAllowed(w)
+		}
+	})
+	api.HandleFunc("/alerts/rules/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			app.getAlertRuleHandler(w, r)
+		case http.MethodPatch:
+			app.updateAlertRuleHandler(w, r)
+		case http.MethodDelete:
+			app.deleteAlertRuleHandler(w, r)
+		default:
+			utils.ReplyMethodNotAllowed(w)
+		}
+	})
+	api.HandleFunc("/alerts/rules/{id}/history", app.alertHistoryHandler)
+
+	// notification routes
+	api.HandleFunc("/notifications/channels", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			app.listNotificationChannelsHandler(w, r)
+		case http.MethodPost:
+			app.createNotificationChannelHandler(w, r)
+		default:
+			utils.ReplyMethodNotAllowed(w)
+		}
+	})
+	api.HandleFunc("/notifications/channels/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			app.getNotificationChannelHandler(w, r)
+		case http.MethodDelete:
+			app.deleteNotificationChannelHandler(w, r)
+		default:
+			utils.ReplyMethodNotAllowed(w)
+		}
+	})
+	api.HandleFunc("/notifications/channels/{id}/test", app.testNotificationChannelHandler)
+	api.HandleFunc("/notifications/channels/{id}/dead-letters", app.deadLettersHandler)
+
+	// admin routes
+	api.HandleFunc("/admin/cache/version", app.cacheVersionHandler)
+	api.HandleFunc("/admin/credentials/reseal", app.credentialsResealHandler)
 
 	// arroyo command routes
-	mux.HandleFunc("/jobs", app.ListJobs)
-	mux.HandleFunc("/jobs/{id}", app.GetJob)
-	mux.HandleFunc("/jobs/run", app.CreatePipeline)
-	mux.HandleFunc("/pipelines", app.ListPipelines)
+	api.HandleFunc("/jobs", app.ListJobs)
+	api.HandleFunc("/jobs/{id}", app.GetJob)
+	api.HandleFunc("/jobs/run", app.CreatePipeline)
+	api.HandleFunc("/pipelines", app.ListPipelines)
 
 	return utils.WithCORS(mux)
 }
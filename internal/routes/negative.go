@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+const (
+	// negativeTTL bounds how long a sensor that starts reporting can still look silent.
+	negativeTTL = 30 * time.Second
+
+	// cacheBypassHeader skips negative cache entries when set to a true value, for debugging.
+	cacheBypassHeader = "X-Cache-Bypass"
+
+	reasonNoReadings    = "no_readings"
+	reasonUnknownSensor = "unknown_sensor"
+)
+
+func replyAggregateMissing(w http.ResponseWriter, reason string) {
+	if reason == reasonUnknownSensor {
+		utils.ReplyNotFound(w, "sensor not found")
+		return
+	}
+	utils.ReplyNotFound(w, "no readings found")
+}
+
+// bypassNegativeCache reports whether the request asked to ignore negative entries.
+func bypassNegativeCache(r *http.Request) bool {
+	bypass, _ := strconv.ParseBool(r.Header.Get(cacheBypassHeader))
+	return bypass
+}
+
+// sensorKnownMissing reports whether a recent lookup found no such sensor.
+func (app *App) sensorKnownMissing(ctx context.Context, sensorID uuid.UUID) bool {
+	missing, err := cache.Get[bool](ctx, app.Cache, app.Codec, app.Keys.UnknownSensor(sensorID.String()))
+	if err != nil || !missing {
+		return false
+	}
+
+	metrics.NegativeCacheHitsTotal.WithLabelValues(reasonUnknownSensor).Inc()
+	return true
+}
+
+// rememberSensorMissing caches that sensorID does not exist.
+func (app *App) rememberSensorMissing(ctx context.Context, sensorID uuid.UUID) {
+	metrics.NegativeCacheMissesTotal.WithLabelValues(reasonUnknownSensor).Inc()
+
+	key := app.Keys.UnknownSensor(sensorID.String())
+	if err := cache.Set(ctx, app.Cache, app.Codec, key, true, negativeTTL); err != nil {
+		app.logger.Warn().Err(err).Str("cache_key", key).Msg("failed to store negative cache entry")
+	}
+}
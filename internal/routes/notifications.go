@@ -0,0 +1,308 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/notify"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+const (
+	defaultChannelMaxAttempts = 5
+	maxChannelMaxAttempts     = 10
+	defaultChannelBackoff     = 5 * time.Second
+	maxChannelBackoff         = 5 * time.Minute
+
+	defaultDeadLettersLimit = 100
+	maxDeadLettersLimit     = 1000
+)
+
+// generateSigningSecret returns a random URL-safe HMAC key for a webhook channel.
+func generateSigningSecret() string {
+	b := make([]byte, 32)
+	// crypto/rand.Read never returns an error on supported platforms
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type notificationChannelRequest struct {
+	Name        string                        `json:"name"`
+	Type        types.NotificationChannelType `json:"type"`
+	URL         string                        `json:"url"`
+	FieldID     *uuid.UUID                    `json:"field_id"`
+	MaxAttempts *int                          `json:"max_attempts"`
+	Backoff     *types.Duration               `json:"backoff"`
+	Enabled     *bool                         `json:"enabled"`
+}
+
+// notificationChannel loads a channel of the caller by its path ID. Channels of other
+// users are reported as not found.
+func (app *App) notificationChannel(w http.ResponseWriter, r *http.Request) (*types.NotificationChannel, bool) {
+	p, ok := app.principal(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	channelID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid channel_id")
+		return nil, false
+	}
+
+	ch, err := app.Store.GetNotificationChannel(p.UserID, channelID)
+	if err != nil {
+		if errors.Is(err, db.ErrChannelNotFound) {
+			utils.ReplyNotFound(w, "notification channel not found")
+			return nil, false
+		}
+		utils.ReplyInternalServerError(w, err.Error())
+		return nil, false
+	}
+
+	return ch, true
+}
+
+func (app *App) listNotificationChannelsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	p, ok := app.principal(w, r)
+	if !ok {
+		return
+	}
+
+	channels, err := app.Store.ListNotificationChannels(r.Context(), p.UserID)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+	for i := range channels {
+		channels[i].Secret = ""
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": channels,
+	})
+}
+
+// createNotificationChannelHandler adds a channel. Webhook and Slack channels post to an
+// HTTPS url, MQTT channels publish to alerts/<field_id>. Webhook channels get a signing
+// secret, returned only in this response.
+func (app *App) createNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	p, ok := app.principal(w, r)
+	if !ok {
+		return
+	}
+
+	var req notificationChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ReplyBadRequest(w, "invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		utils.ReplyBadRequest(w, "missing name")
+		return
+	}
+
+	switch req.Type {
+	case types.NotificationChannelWebhook, types.NotificationChannelSlack:
+		if err := notify.CheckURL(req.URL); err != nil {
+			utils.ReplyBadRequest(w, err.Error())
+			return
+		}
+	case types.NotificationChannelMQTT:
+		if req.URL != "" {
+			utils.ReplyBadRequest(w, "mqtt channels do not take a url")
+			return
+		}
+	default:
+		utils.ReplyBadRequest(w, "type must be one of webhook, slack or mqtt")
+		return
+	}
+
+	ch := types.NotificationChannel{
+		UserID:      p.UserID,
+		Name:        req.Name,
+		Type:        req.Type,
+		URL:         req.URL,
+		MaxAttempts: defaultChannelMaxAttempts,
+		Backoff:     types.Duration(defaultChannelBackoff),
+		Enabled:     true,
+	}
+	if req.MaxAttempts != nil {
+		if *req.MaxAttempts < 1 || *req.MaxAttempts > maxChannelMaxAttempts {
+			utils.ReplyBadRequest(w, "max_attempts must be between 1 and "+strconv.Itoa(maxChannelMaxAttempts))
+			return
+		}
+		ch.MaxAttempts = *req.MaxAttempts
+	}
+	if req.Backoff != nil {
+		if *req.Backoff <= 0 || time.Duration(*req.Backoff) > maxChannelBackoff {
+			utils.ReplyBadRequest(w, "backoff must be positive and at most "+maxChannelBackoff.String())
+			return
+		}
+		ch.Backoff = *req.Backoff
+	}
+	if req.Enabled != nil {
+		ch.Enabled = *req.Enabled
+	}
+
+	if req.FieldID != nil {
+		if _, ok := app.authorizeField(w, r, *req.FieldID); !ok {
+			return
+		}
+		ch.FieldID = req.FieldID
+	}
+
+	if ch.Type == types.NotificationChannelWebhook {
+		ch.Secret = generateSigningSecret()
+	}
+
+	created, err := app.Store.CreateNotificationChannel(ch)
+	if err != nil {
+		app.logger.Error().Err(err).Msg("failed to create notification channel")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	app.logger.Info().Str("channel_id", created.ChannelID.String()).Str("type", string(created.Type)).Msg("notification channel created")
+
+	utils.ReplyJSON(w, http.StatusCreated, utils.Body{
+		"data": created,
+	})
+}
+
+func (app *App) getNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	ch, ok := app.notificationChannel(w, r)
+	if !ok {
+		return
+	}
+	ch.Secret = ""
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": ch,
+	})
+}
+
+// deleteNotificationChannelHandler removes a channel. Its dead letters are kept.
+func (app *App) deleteNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	ch, ok := app.notificationChannel(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.Store.DeleteNotificationChannel(ch.UserID, ch.ChannelID); err != nil {
+		app.logger.Error().Err(err).Str("channel_id", ch.ChannelID.String()).Msg("failed to delete notification channel")
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+
+	app.logger.Info().Str("channel_id", ch.ChannelID.String()).Msg("notification channel deleted")
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": map[string]any{
+			"channel_id": ch.ChannelID,
+		},
+	})
+}
+
+// testNotificationChannelHandler delivers a synthetic alert to a channel once, without
+// retries, and reports the outcome. Disabled channels can be tested too.
+func (app *App) testNotificationChannelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	ch, ok := app.notificationChannel(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	n := notify.TestNotification(*ch)
+	if err := notify.NewSender(app.EmqxClient).Send(ctx, *ch, n); err != nil {
+		app.logger.Warn().Err(err).Str("channel_id", ch.ChannelID.String()).Msg("test notification failed")
+		utils.ReplyJSON(w, http.StatusBadGateway, utils.Body{
+			"error": "delivery failed: " + err.Error(),
+		})
+		return
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": map[string]any{
+			"channel_id": ch.ChannelID,
+			"event_id":   n.Event.EventID,
+			"delivered":  true,
+		},
+	})
+}
+
+// deadLettersHandler lists the latest notifications a channel gave up on.
+func (app *App) deadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	limit := defaultDeadLettersLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxDeadLettersLimit {
+			utils.ReplyBadRequest(w, "limit must be between 1 and "+strconv.Itoa(maxDeadLettersLimit))
+			return
+		}
+	}
+
+	ch, ok := app.notificationChannel(w, r)
+	if !ok {
+		return
+	}
+
+	letters, err := app.Store.GetDeadLetters(ch.ChannelID, limit)
+	if err != nil {
+		utils.ReplyInternalServerError(w, err.Error())
+		return
+	}
+	if letters == nil {
+		letters = []types.DeadLetter{}
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": letters,
+	})
+}
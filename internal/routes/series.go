@@ -0,0 +1,234 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+const (
+	// seriesChunkSteps is how many steps are computed and cached together.
+	// Chunks are aligned to their span so overlapping requests hit the same keys.
+	seriesChunkSteps = 60
+	// maxSeriesChunkSpan bounds the readings scanned for one chunk; large steps get fewer
+	// steps per chunk, down to one.
+	maxSeriesChunkSpan = 7 * 24 * time.Hour
+	maxSeriesPoints    = 2000
+	seriesChunkTTL     = time.Hour
+)
+
+// seriesBuilder folds readings, received in ascending timestamp order, into step-aligned points.
+type seriesBuilder struct {
+	step   time.Duration
+	end    time.Time
+	points []types.SeriesPoint
+}
+
+func (b *seriesBuilder) add(e types.Entry) error {
+	if !e.Timestamp.Before(b.end) {
+		return nil
+	}
+
+	start := e.Timestamp.UTC().Truncate(b.step)
+	if n := len(b.points); n == 0 || !b.points[n-1].Start.Equal(start) {
+		b.points = append(b.points, types.SeriesPoint{
+			Start: start,
+			Min:   e.Value,
+			Max:   e.Value,
+			First: e.Value,
+		})
+	}
+
+	p := &b.points[len(b.points)-1]
+	p.Count++
+	p.Avg += (e.Value - p.Avg) / float64(p.Count)
+	p.Min = min(p.Min, e.Value)
+	p.Max = max(p.Max, e.Value)
+	p.Last = e.Value
+
+	return nil
+}
+
+func (app *App) aggregateSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	ctx, span := otel.Tracer("nostradamus-api").Start(r.Context(), "HTTP /aggregate/series")
+	defer span.End()
+
+	start := time.Now()
+
+	defer func() {
+		metrics.HttpRequestLatencySeconds.WithLabelValues("GET").Observe(time.Since(start).Seconds())
+	}()
+
+	q := r.URL.Query()
+
+	sensorIDStr := q.Get("sensor_id")
+	stepStr := q.Get("step")
+
+	missing := []string{}
+	if sensorIDStr == "" {
+		missing = append(missing, "sensor_id")
+	}
+	if stepStr == "" {
+		missing = append(missing, "step")
+	}
+	if len(missing) > 0 {
+		app.logger.Error().Strs("missing_params", missing).Msg("invalid request")
+		utils.ReplyBadRequest(w, "missing query params")
+		return
+	}
+
+	sensorID, err := uuid.Parse(sensorIDStr)
+	if err != nil {
+		utils.ReplyBadRequest(w, "invalid sensor_id")
+		return
+	}
+
+	step, err := time.ParseDuration(stepStr)
+	if err != nil || step < time.Second {
+		utils.ReplyBadRequest(w, "invalid step")
+		return
+	}
+	if step > maxAggregateWindow {
+		utils.ReplyBadRequest(w, fmt.Sprintf("step must not exceed %s", maxAggregateWindow))
+		return
+	}
+
+	now := time.Now().UTC()
+
+	to := now
+	if toStr := q.Get("to"); toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid to, expected RFC3339")
+			return
+		}
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if fromStr := q.Get("from"); fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid from, expected RFC3339")
+			return
+		}
+	}
+
+	// Align the window to whole steps
+	from = from.UTC().Truncate(step)
+	if aligned := to.UTC().Truncate(step); aligned.Equal(to) {
+		to = aligned
+	} else {
+		to = aligned.Add(step)
+	}
+
+	if !from.Before(to) {
+		utils.ReplyBadRequest(w, "from must be before to")
+		return
+	}
+	if to.Sub(from) > maxAggregateWindow {
+		utils.ReplyBadRequest(w, fmt.Sprintf("window must not exceed %s", maxAggregateWindow))
+		return
+	}
+	if to.Sub(from)/step > maxSeriesPoints {
+		utils.ReplyBadRequest(w, fmt.Sprintf("window yields more than %d points, increase step", maxSeriesPoints))
+		return
+	}
+
+	if _, ok := app.authorizeSensor(w, r, sensorID); !ok {
+		return
+	}
+
+	sType, ok := app.resolveSensorType(w, r, sensorID)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("sensor.id", sensorID.String()),
+		attribute.Int("sensor.type", sType),
+		attribute.String("series.step", step.String()),
+	)
+
+	chunkSpan := step * time.Duration(max(1, min(seriesChunkSteps, int64(maxSeriesChunkSpan/step))))
+	series := []types.SeriesPoint{}
+
+	for chunk := from.Truncate(chunkSpan); chunk.Before(to); chunk = chunk.Add(chunkSpan) {
+		chunkEnd := chunk.Add(chunkSpan)
+		// Only chunks entirely in the past are stable enough to cache
+		complete := !chunkEnd.After(now)
+		cacheKey := app.Keys.Series(sensorID.String(), sType, step, chunk)
+
+		var points []types.SeriesPoint
+		cached := false
+
+		if complete {
+			points, err = cache.Get[[]types.SeriesPoint](ctx, app.Cache, app.Codec, cacheKey)
+			if err == nil {
+				cached = true
+			} else if errors.Is(err, cache.ErrCorrupt) {
+				app.logger.Warn().Err(err).Str("cache_key", cacheKey).Msg("invalid cache entry")
+			}
+		}
+
+		if !cached {
+			end := chunkEnd
+			if !complete && to.Before(end) {
+				end = to
+			}
+			// Steps before from are not needed, and a chunk missing them is not cached
+			begin := chunk
+			if begin.Before(from) {
+				begin = from
+			}
+
+			builder := &seriesBuilder{step: step, end: end}
+			if err := app.Store.ScanReadings(ctx, sensorID.String(), sType, begin, end, builder.add); err != nil {
+				app.logger.Error().Err(err).Msg("failed to scan readings from database")
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				utils.ReplyInternalServerError(w, err.Error())
+				return
+			}
+			points = builder.points
+			if points == nil {
+				points = []types.SeriesPoint{}
+			}
+
+			if complete && begin.Equal(chunk) {
+				if err := cache.Set(ctx, app.Cache, app.Codec, cacheKey, points, seriesChunkTTL); err != nil {
+					app.logger.Error().Err(err).Str("cache_key", cacheKey).Msg("failed to store series chunk in cache")
+				}
+			}
+		}
+
+		for _, p := range points {
+			if !p.Start.Before(from) && p.Start.Before(to) {
+				series = append(series, p)
+			}
+		}
+	}
+
+	utils.ReplyJSON(w, http.StatusOK, utils.Body{
+		"data": series,
+		"from": from,
+		"to":   to,
+		"step": step.String(),
+	})
+	span.SetStatus(codes.Ok, "")
+}
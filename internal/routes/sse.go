@@ -0,0 +1,234 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+	"github.com/ntentasd/nostradamus-api/pkg/utils"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	// sseBuffer is how many readings a client may fall behind before it is dropped
+	sseBuffer = 256
+	// sseRetry tells EventSource how soon to reconnect, in milliseconds
+	sseRetry = 2000
+)
+
+// streamTarget is a sensor a stream follows.
+type streamTarget struct {
+	sensorID uuid.UUID
+	sType    int
+}
+
+// writeEvent writes one SSE event. Readings use their unix millis as event ID, which
+// is also their score in the latest ZSET, so Last-Event-ID can resume from the cache.
+func writeEvent(w io.Writer, id, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// resolveStreamTargets reads sensor_id or field_id and checks the caller may follow it.
+// On failure it replies to the client and returns false.
+func (app *App) resolveStreamTargets(w http.ResponseWriter, r *http.Request) ([]streamTarget, bool) {
+	q := r.URL.Query()
+
+	switch {
+	case q.Get("sensor_id") != "" && q.Get("field_id") == "":
+		sensorID, err := uuid.Parse(q.Get("sensor_id"))
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid sensor_id")
+			return nil, false
+		}
+
+		if _, ok := app.authorizeSensor(w, r, sensorID); !ok {
+			return nil, false
+		}
+
+		sType, ok := app.resolveSensorType(w, r, sensorID)
+		if !ok {
+			return nil, false
+		}
+
+		return []streamTarget{{sensorID, sType}}, true
+	case q.Get("field_id") != "" && q.Get("sensor_id") == "":
+		fieldID, err := uuid.Parse(q.Get("field_id"))
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid field_id")
+			return nil, false
+		}
+
+		if _, ok := app.authorizeField(w, r, fieldID); !ok {
+			return nil, false
+		}
+
+		sensors, _, err := app.Store.GetSensorsByFieldID(fieldID)
+		if err != nil {
+			utils.ReplyInternalServerError(w, err.Error())
+			return nil, false
+		}
+
+		var targets []streamTarget
+		for _, s := range sensors {
+			if s.DecommissionedAt == nil {
+				targets = append(targets, streamTarget{s.SensorID, int(s.SensorType)})
+			}
+		}
+		return targets, true
+	default:
+		utils.ReplyBadRequest(w, "exactly one of sensor_id or field_id is required")
+		return nil, false
+	}
+}
+
+// backlog returns the cached readings of targets after since, oldest first.
+func (app *App) backlog(ctx context.Context, targets []streamTarget, since time.Time) []types.Reading {
+	var readings []types.Reading
+	for _, t := range targets {
+		key := app.Keys.Latest(t.sensorID.String(), t.sType)
+		entries, err := app.Cache.FetchLast(ctx, key, cache.MaxLatestEntries)
+		if err != nil {
+			app.logger.Warn().Err(err).Str("cache_key", key).Msg("failed to fetch stream backlog")
+			continue
+		}
+
+		for _, e := range entries {
+			if e.Timestamp.After(since) {
+				readings = append(readings, types.Reading{
+					SensorID:   t.sensorID,
+					SensorType: types.SensorType(t.sType),
+					Timestamp:  e.Timestamp.UTC(),
+					Value:      e.Value,
+				})
+			}
+		}
+	}
+
+	slices.SortFunc(readings, func(a, b types.Reading) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return readings
+}
+
+// streamReadingsHandler pushes new readings of a sensor, or of every active sensor of a
+// field, as Server-Sent Events. Clients that fall behind are sent a lagged event and
+// disconnected; reconnecting with Last-Event-ID replays what they missed from the cache.
+func (app *App) streamReadingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ReplyMethodNotAllowed(w)
+		return
+	}
+
+	targets, ok := app.resolveStreamTargets(w, r)
+	if !ok {
+		return
+	}
+
+	var since time.Time
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		ms, err := strconv.ParseInt(lastID, 10, 64)
+		if err != nil {
+			utils.ReplyBadRequest(w, "invalid Last-Event-ID")
+			return
+		}
+		since = time.UnixMilli(ms)
+	}
+
+	sensorIDs := make([]uuid.UUID, 0, len(targets))
+	for _, t := range targets {
+		sensorIDs = append(sensorIDs, t.sensorID)
+	}
+
+	// Subscribe before reading the backlog so nothing falls in between
+	sub := app.Hub.Subscribe(sensorIDs, sseBuffer)
+	defer app.Hub.Unsubscribe(sub)
+
+	metrics.StreamClients.WithLabelValues("sse").Inc()
+	defer metrics.StreamClients.WithLabelValues("sse").Dec()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// lastSent deduplicates readings delivered by both the backlog and the hub
+	lastSent := make(map[uuid.UUID]time.Time, len(targets))
+	send := func(reading types.Reading) error {
+		// The cache keeps millisecond precision, match it so replays compare equal
+		reading.Timestamp = reading.Timestamp.Truncate(time.Millisecond)
+		if !reading.Timestamp.After(lastSent[reading.SensorID]) {
+			return nil
+		}
+		lastSent[reading.SensorID] = reading.Timestamp
+
+		id := strconv.FormatInt(reading.Timestamp.UnixMilli(), 10)
+		if err := writeEvent(w, id, "reading", reading); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry); err != nil {
+		return
+	}
+
+	if !since.IsZero() {
+		for _, reading := range app.backlog(r.Context(), targets, since) {
+			if err := send(reading); err != nil {
+				return
+			}
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(sseHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Lagged():
+			_ = writeEvent(w, "", "lagged", utils.Body{"error": "client too slow, reconnect to resume"})
+			_ = rc.Flush()
+			return
+		case <-ticker.C:
+			if err := writeEvent(w, "", "heartbeat", utils.Body{"time": time.Now().UTC()}); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case reading := <-sub.C:
+			if err := send(reading); err != nil {
+				return
+			}
+		}
+	}
+}
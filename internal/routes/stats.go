@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/stats"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+// daySummaryTTL applies to summaries of completed days, whose readings no longer change.
+const daySummaryTTL = 24 * time.Hour
+
+// parseStats validates a comma separated stats list (median, stddev, variance, pNN)
+// and returns it sorted and deduplicated so it can be used as part of a cache key.
+func parseStats(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var requested []string
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		switch {
+		case s == "median", s == "stddev", s == "variance":
+		case strings.HasPrefix(s, "p"):
+			p, err := strconv.ParseFloat(s[1:], 64)
+			if err != nil || p <= 0 || p > 100 {
+				return nil, fmt.Errorf("invalid percentile %q", s)
+			}
+		default:
+			return nil, fmt.Errorf("unknown stat %q", s)
+		}
+		requested = append(requested, s)
+	}
+
+	slices.Sort(requested)
+	return slices.Compact(requested), nil
+}
+
+// applyStats fills the requested optional statistics of agg from a summary.
+func applyStats(agg *types.Aggregate, s *stats.Summary, requested []string) {
+	for _, name := range requested {
+		switch name {
+		case "median":
+			v := s.Quantile(0.5)
+			agg.Median = &v
+		case "stddev":
+			v := s.StdDev()
+			agg.StdDev = &v
+		case "variance":
+			v := s.Variance()
+			agg.Variance = &v
+		default:
+			// parseStats guarantees a valid pNN
+			p, _ := strconv.ParseFloat(name[1:], 64)
+			if agg.Percentiles == nil {
+				agg.Percentiles = map[string]float64{}
+			}
+			agg.Percentiles[name] = s.Quantile(p / 100)
+		}
+	}
+}
+
+// summarize builds a summary of all readings between from and to. Whole days that have
+// already ended are read from (and written to) the cache as partial summaries; only the
+// partial days at the edges of the window are scanned on every call.
+func (app *App) summarize(ctx context.Context, sensorID string, sType int, from, to time.Time) (*stats.Summary, error) {
+	total := stats.NewSummary()
+	now := time.Now().UTC()
+
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	for day := first; !day.After(to); day = day.Add(24 * time.Hour) {
+		dayEnd := day.Add(24 * time.Hour)
+
+		// Keep segments disjoint, the next day starts at dayEnd
+		segStart, segEnd := day, dayEnd.Add(-time.Nanosecond)
+		if from.After(segStart) {
+			segStart = from
+		}
+		if to.Before(segEnd) {
+			segEnd = to
+		}
+
+		whole := segStart.Equal(day) && segEnd.Equal(dayEnd.Add(-time.Nanosecond)) && !dayEnd.After(now)
+		cacheKey := app.Keys.DaySummary(sensorID, sType, day)
+
+		if whole {
+			partial, err := cache.Get[stats.Summary](ctx, app.Cache, app.Codec, cacheKey)
+			if err == nil {
+				total.Merge(&partial)
+				continue
+			}
+			if errors.Is(err, cache.ErrCorrupt) {
+				app.logger.Warn().Err(err).Str("cache_key", cacheKey).Msg("invalid cache entry")
+			}
+		}
+
+		partial := stats.NewSummary()
+		err := app.Store.ScanReadings(ctx, sensorID, sType, segStart, segEnd, func(e types.Entry) error {
+			partial.Add(e.Value)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if whole {
+			if err := cache.Set(ctx, app.Cache, app.Codec, cacheKey, partial, daySummaryTTL); err != nil {
+				app.logger.Error().Err(err).Str("cache_key", cacheKey).Msg("failed to store day summary in cache")
+			}
+		}
+
+		total.Merge(partial)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,201 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ntentasd/nostradamus-api/internal/cache"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+const (
+	// warmupLatestN covers the default and most common n of /latest.
+	warmupLatestN = 100
+)
+
+// warmupWindows are the aggregate windows dashboards ask for most.
+var warmupWindows = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+// WarmupProgress describes the current or last warm-up run.
+type WarmupProgress struct {
+	Running    bool       `json:"running"`
+	Total      int64      `json:"total"`
+	Done       int64      `json:"done"`
+	Failed     int64      `json:"failed"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Warmer preloads the latest readings and common aggregate windows of every active
+// sensor, once at startup and then periodically. The cache is shared, so a single replica
+// running it is enough; each one adds a full pass over Scylla.
+type Warmer struct {
+	app         *App
+	interval    time.Duration
+	concurrency int
+	cancelCtx   context.CancelFunc
+	logger      zerolog.Logger
+
+	// warmed is set once the first run has finished, successfully or not
+	warmed atomic.Bool
+
+	mu       sync.Mutex
+	progress WarmupProgress
+	total    atomic.Int64
+	done     atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewWarmer creates a cache warm-up worker and attaches it to app, so /readyz can report
+// on it. An interval of zero only warms up at startup.
+func NewWarmer(app *App, interval time.Duration, concurrency int, logger zerolog.Logger) *Warmer {
+	w := &Warmer{
+		app:         app,
+		interval:    interval,
+		concurrency: concurrency,
+		logger:      logger,
+	}
+	app.warmer = w
+	return w
+}
+
+func (w *Warmer) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancelCtx = cancel
+
+	go func() {
+		w.run(ctx)
+		w.warmed.Store(true)
+
+		if w.interval <= 0 {
+			return
+		}
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.run(ctx)
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the background worker.
+func (w *Warmer) Stop() {
+	if w.cancelCtx != nil {
+		w.cancelCtx()
+	}
+}
+
+// Warmed reports whether the first warm-up run has finished.
+func (w *Warmer) Warmed() bool {
+	return w.warmed.Load()
+}
+
+func (w *Warmer) Progress() WarmupProgress {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	p := w.progress
+	p.Total = w.total.Load()
+	p.Done = w.done.Load()
+	p.Failed = w.failed.Load()
+	return p
+}
+
+func (w *Warmer) run(ctx context.Context) {
+	sensors, err := w.app.Store.ListActiveSensors(ctx)
+	if err != nil {
+		w.logger.Error().Err(err).Msg("failed to list sensors for cache warm-up")
+		return
+	}
+
+	started := time.Now().UTC()
+	w.mu.Lock()
+	w.progress = WarmupProgress{Running: true, StartedAt: &started}
+	w.mu.Unlock()
+	w.total.Store(int64(len(sensors)))
+	w.done.Store(0)
+	w.failed.Store(0)
+	metrics.CacheWarmupSensors.WithLabelValues("total").Set(float64(len(sensors)))
+
+	w.logger.Info().Int("sensors", len(sensors)).Msg("cache warm-up started")
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(w.concurrency)
+
+	for _, s := range sensors {
+		g.Go(func() error {
+			if err := w.warmSensor(gctx, s); err != nil {
+				w.failed.Add(1)
+				w.logger.Warn().Err(err).Str("sensor_id", s.SensorID.String()).Msg("failed to warm up sensor")
+			}
+
+			done := w.done.Add(1)
+			metrics.CacheWarmupSensors.WithLabelValues("done").Set(float64(done))
+			metrics.CacheWarmupSensors.WithLabelValues("failed").Set(float64(w.failed.Load()))
+			if done%100 == 0 {
+				w.logger.Info().Int64("done", done).Int("total", len(sensors)).Msg("cache warm-up progress")
+			}
+			// Failures are per sensor, keep going
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	finished := time.Now().UTC()
+	w.mu.Lock()
+	w.progress.Running = false
+	w.progress.FinishedAt = &finished
+	w.mu.Unlock()
+
+	w.logger.Info().
+		Int64("done", w.done.Load()).
+		Int64("failed", w.failed.Load()).
+		Dur("took", finished.Sub(started)).
+		Msg("cache warm-up finished")
+}
+
+func (w *Warmer) warmSensor(ctx context.Context, s types.Sensor) error {
+	app := w.app
+	sensorID := s.SensorID.String()
+	sType := int(s.SensorType)
+
+	entries, err := app.Store.GetLastValues(ctx, sensorID, sType, warmupLatestN)
+	if err != nil {
+		return err
+	}
+	if err := app.Cache.StoreMany(ctx, app.Keys.Latest(sensorID, sType), entries, cache.LatestTTL); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	gen := app.aggregateGeneration(ctx, sensorID)
+	for _, window := range warmupWindows {
+		req := aggregateRequest{
+			cacheKey: app.Keys.Aggregate(sensorID, sType, now, window.String(), gen, nil),
+			sensorID: sensorID,
+			sType:    sType,
+			window:   window,
+		}
+
+		_, err := app.loadAggregate(ctx, req)
+		if err != nil && !errors.Is(err, errNoReadings) && !errors.Is(err, errUnknownSensor) {
+			return err
+		}
+	}
+
+	return nil
+}
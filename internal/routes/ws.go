@@ -0,0 +1,341 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"github.com/ntentasd/nostradamus-api/internal/auth"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/internal/stream"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = 25 * time.Second
+	wsMaxMessage   = 64 * 1024
+	// wsMaxSensors bounds how many sensors one connection may follow
+	wsMaxSensors = 500
+)
+
+// The API sits behind the same permissive CORS policy as the REST routes and
+// authenticates every handshake, so any origin may connect.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// wsThreshold raises an alert when a reading leaves [Min, Max].
+type wsThreshold struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// wsRequest is a control message sent by the client.
+type wsRequest struct {
+	Type      string       `json:"type"`
+	ID        string       `json:"id,omitempty"`
+	SensorIDs []uuid.UUID  `json:"sensor_ids,omitempty"`
+	FieldIDs  []uuid.UUID  `json:"field_ids,omitempty"`
+	Threshold *wsThreshold `json:"threshold,omitempty"`
+}
+
+// wsMessage is anything sent to the client.
+type wsMessage struct {
+	Type      string      `json:"type"`
+	ID        string      `json:"id,omitempty"`
+	SensorIDs []uuid.UUID `json:"sensor_ids,omitempty"`
+	Data      any         `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type wsAlert struct {
+	SensorID  uuid.UUID `json:"sensor_id"`
+	State     string    `json:"state"`
+	Bound     string    `json:"bound"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// wsConn is the state of one WebSocket client. Only the handler loop, through handle and
+// evaluate, touches it; readLoop merely forwards requests.
+type wsConn struct {
+	app       *App
+	principal *auth.Principal
+
+	// sensors maps every followed sensor to the fields that brought it in,
+	// a nil set means it was subscribed to directly
+	sensors    map[uuid.UUID]map[uuid.UUID]struct{}
+	direct     map[uuid.UUID]bool
+	thresholds map[uuid.UUID]*wsThreshold
+	// firing holds the bound currently breached per sensor
+	firing map[uuid.UUID]string
+}
+
+// wsHandler upgrades to a WebSocket over which clients subscribe to sensors and fields
+// with JSON control messages and receive their readings and threshold alerts.
+func (app *App) wsHandler(w http.ResponseWriter, r *http.Request) {
+	p, ok := app.principal(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already replied
+		app.logger.Warn().Err(err).Msg("websocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	metrics.StreamClients.WithLabelValues("websocket").Inc()
+	defer metrics.StreamClients.WithLabelValues("websocket").Dec()
+
+	sub := app.Hub.Subscribe(nil, sseBuffer)
+	defer app.Hub.Unsubscribe(sub)
+
+	c := &wsConn{
+		app:        app,
+		principal:  p,
+		sensors:    make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		direct:     make(map[uuid.UUID]bool),
+		thresholds: make(map[uuid.UUID]*wsThreshold),
+		firing:     make(map[uuid.UUID]string),
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	requests := make(chan wsRequest)
+	go func() {
+		defer cancel()
+		c.readLoop(ctx, conn, requests)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	write := func(msg wsMessage) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(msg) == nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-requests:
+			if !write(c.handle(ctx, sub, req)) {
+				return
+			}
+		case <-sub.Lagged():
+			write(wsMessage{Type: "lagged", Error: "client too slow, reconnect and resubscribe"})
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "lagged"),
+				time.Now().Add(wsWriteTimeout))
+			return
+		case reading := <-sub.C:
+			if !write(wsMessage{Type: "reading", Data: reading}) {
+				return
+			}
+			if alert := c.evaluate(reading); alert != nil {
+				if !write(wsMessage{Type: "alert", Data: alert}) {
+					return
+				}
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *wsConn) readLoop(ctx context.Context, conn *websocket.Conn, requests chan<- wsRequest) {
+	conn.SetReadLimit(wsMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+
+		var req wsRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			req = wsRequest{Type: "invalid"}
+		}
+
+		select {
+		case requests <- req:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handle applies a control message and returns the reply.
+func (c *wsConn) handle(ctx context.Context, sub *stream.Subscription, req wsRequest) wsMessage {
+	switch req.Type {
+	case "ping":
+		return wsMessage{Type: "pong", ID: req.ID}
+	case "subscribe":
+		added, err := c.subscribe(ctx, req)
+		if err != "" {
+			return wsMessage{Type: "error", ID: req.ID, Error: err}
+		}
+		c.app.Hub.Add(sub, added...)
+		return wsMessage{Type: "subscribed", ID: req.ID, SensorIDs: added}
+	case "unsubscribe":
+		removed := c.unsubscribe(req)
+		c.app.Hub.Remove(sub, removed...)
+		return wsMessage{Type: "unsubscribed", ID: req.ID, SensorIDs: removed}
+	default:
+		return wsMessage{Type: "error", ID: req.ID, Error: "unknown message type, expected subscribe, unsubscribe or ping"}
+	}
+}
+
+// subscribe checks ownership of every requested sensor and field and returns the sensors
+// to add, or a client facing error. A failing request is rejected as a whole.
+func (c *wsConn) subscribe(ctx context.Context, req wsRequest) ([]uuid.UUID, string) {
+	type source struct {
+		sensorID uuid.UUID
+		fieldID  *uuid.UUID
+	}
+	var sources []source
+
+	for _, sensorID := range req.SensorIDs {
+		owned, err := c.app.sensorOwned(ctx, c.principal, sensorID)
+		if err != nil {
+			return nil, "failed to authorize sensor"
+		}
+		if !owned {
+			return nil, "sensor not found: " + sensorID.String()
+		}
+		sources = append(sources, source{sensorID: sensorID})
+	}
+
+	for _, fieldID := range req.FieldIDs {
+		field, err := c.app.ownedField(c.principal, fieldID)
+		if err != nil {
+			return nil, "failed to authorize field"
+		}
+		if field == nil {
+			return nil, "field not found: " + fieldID.String()
+		}
+
+		sensors, _, err := c.app.Store.GetSensorsByFieldID(fieldID)
+		if err != nil {
+			return nil, "failed to list field sensors"
+		}
+		for _, s := range sensors {
+			if s.DecommissionedAt == nil {
+				sources = append(sources, source{s.SensorID, &fieldID})
+			}
+		}
+	}
+
+	// Check capacity before touching any state, so a rejected request changes nothing
+	fresh := make(map[uuid.UUID]struct{})
+	for _, s := range sources {
+		if _, ok := c.sensors[s.sensorID]; !ok {
+			fresh[s.sensorID] = struct{}{}
+		}
+	}
+	if len(c.sensors)+len(fresh) > wsMaxSensors {
+		return nil, "too many sensors on one connection"
+	}
+
+	var added []uuid.UUID
+	for _, s := range sources {
+		if _, ok := c.sensors[s.sensorID]; !ok {
+			c.sensors[s.sensorID] = make(map[uuid.UUID]struct{})
+			added = append(added, s.sensorID)
+		}
+
+		if s.fieldID != nil {
+			c.sensors[s.sensorID][*s.fieldID] = struct{}{}
+		} else {
+			c.direct[s.sensorID] = true
+		}
+
+		if req.Threshold != nil {
+			c.thresholds[s.sensorID] = req.Threshold
+			delete(c.firing, s.sensorID)
+		}
+	}
+
+	return added, ""
+}
+
+// unsubscribe drops sensors no longer wanted directly or through any field.
+func (c *wsConn) unsubscribe(req wsRequest) []uuid.UUID {
+	for _, sensorID := range req.SensorIDs {
+		delete(c.direct, sensorID)
+	}
+	for _, fieldID := range req.FieldIDs {
+		for _, fields := range c.sensors {
+			delete(fields, fieldID)
+		}
+	}
+
+	var removed []uuid.UUID
+	for sensorID, fields := range c.sensors {
+		if len(fields) == 0 && !c.direct[sensorID] {
+			delete(c.sensors, sensorID)
+			delete(c.thresholds, sensorID)
+			delete(c.firing, sensorID)
+			removed = append(removed, sensorID)
+		}
+	}
+	return removed
+}
+
+// evaluate returns an alert when a reading crosses the sensor's threshold, either
+// leaving the allowed range (firing) or returning into it (resolved).
+func (c *wsConn) evaluate(r types.Reading) *wsAlert {
+	t := c.thresholds[r.SensorID]
+	if t == nil {
+		return nil
+	}
+
+	var bound string
+	var limit float64
+	switch {
+	case t.Min != nil && r.Value < *t.Min:
+		bound, limit = "min", *t.Min
+	case t.Max != nil && r.Value > *t.Max:
+		bound, limit = "max", *t.Max
+	}
+
+	prev := c.firing[r.SensorID]
+	switch {
+	case bound != "" && bound != prev:
+		c.firing[r.SensorID] = bound
+		return &wsAlert{r.SensorID, "firing", bound, limit, r.Value, r.Timestamp}
+	case bound == "" && prev != "":
+		delete(c.firing, r.SensorID)
+		// The threshold may have been replaced since, only dereference the breached bound
+		switch {
+		case prev == "min" && t.Min != nil:
+			limit = *t.Min
+		case prev == "max" && t.Max != nil:
+			limit = *t.Max
+		}
+		return &wsAlert{r.SensorID, "resolved", prev, limit, r.Value, r.Timestamp}
+	default:
+		return nil
+	}
+}
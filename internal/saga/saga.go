@@ -0,0 +1,61 @@
+// Package saga runs multi-system operations as a sequence of steps that are
+// compensated in reverse order when a later step fails.
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// Step is a single action of a saga. Compensate undoes Do and may be nil for steps
+// that need no cleanup.
+type Step struct {
+	Name       string
+	Do         func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+// StepError reports the step that failed and any compensation that could not be applied.
+type StepError struct {
+	Step          string
+	Err           error
+	Compensations []error
+}
+
+func (e *StepError) Error() string {
+	if len(e.Compensations) == 0 {
+		return fmt.Sprintf("%s: %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("%s: %v (compensation failed: %v)", e.Step, e.Err, errors.Join(e.Compensations...))
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Run executes steps in order. When a step fails, the completed steps are compensated
+// in reverse order and a *StepError is returned.
+func Run(ctx context.Context, logger zerolog.Logger, steps ...Step) error {
+	for i, step := range steps {
+		if err := step.Do(ctx); err != nil {
+			logger.Warn().Err(err).Str("step", step.Name).Msg("saga step failed, compensating")
+
+			stepErr := &StepError{Step: step.Name, Err: err}
+			for j := i - 1; j >= 0; j-- {
+				if steps[j].Compensate == nil {
+					continue
+				}
+				// Compensate even if the request context is gone
+				if cerr := steps[j].Compensate(context.WithoutCancel(ctx)); cerr != nil {
+					logger.Error().Err(cerr).Str("step", steps[j].Name).Msg("saga compensation failed")
+					stepErr.Compensations = append(stepErr.Compensations, fmt.Errorf("%s: %w", steps[j].Name, cerr))
+				}
+			}
+			return stepErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package secrets envelope-encrypts small secrets, such as MQTT passwords, before they are stored.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown encryption key")
+	ErrMalformed  = errors.New("malformed ciphertext")
+)
+
+// Keyring holds the key-encryption keys by ID. The primary key wraps new secrets,
+// the others are kept so secrets sealed before a key rotation can still be opened.
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+// ParseKeyring parses a comma or newline separated list of id=base64key entries.
+// The first entry is the primary key. Keys must be 16, 24 or 32 bytes long.
+func ParseKeyring(spec string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte)}
+
+	entries := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(entry, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid key entry, expected id=base64key")
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if _, dup := k.keys[id]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", id)
+		}
+
+		k.keys[id] = key
+		if k.primary == "" {
+			k.primary = id
+		}
+	}
+
+	if k.primary == "" {
+		return nil, fmt.Errorf("keyring is empty")
+	}
+
+	return k, nil
+}
+
+// LoadKeyring reads a keyring in the ParseKeyring format from a file.
+func LoadKeyring(path string) (*Keyring, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	return ParseKeyring(string(b))
+}
+
+// PrimaryID returns the ID of the key used by Seal.
+func (k *Keyring) PrimaryID() string {
+	return k.primary
+}
+
+// Seal encrypts plaintext under a fresh data key, which is in turn wrapped with the primary key.
+// aad binds the ciphertext to its context (e.g. the owning sensor) and must be passed to Open.
+func (k *Keyring) Seal(plaintext, aad []byte) (keyID string, sealed string, err error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", "", err
+	}
+
+	wrapped, err := seal(k.keys[k.primary], dek, []byte(k.primary))
+	if err != nil {
+		return "", "", err
+	}
+
+	ct, err := seal(dek, plaintext, aad)
+	if err != nil {
+		return "", "", err
+	}
+
+	return k.primary, base64.RawStdEncoding.EncodeToString(wrapped) + "." + base64.RawStdEncoding.EncodeToString(ct), nil
+}
+
+// Open reverses Seal using the key identified by keyID.
+func (k *Keyring) Open(keyID, sealed string, aad []byte) ([]byte, error) {
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+
+	wrappedStr, ctStr, ok := strings.Cut(sealed, ".")
+	if !ok {
+		return nil, ErrMalformed
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(wrappedStr)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	ct, err := base64.RawStdEncoding.DecodeString(ctStr)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	dek, err := open(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dek, ct, aad)
+}
+
+// seal returns nonce || AES-GCM(key, plaintext).
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	nonce, ct := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,135 @@
+// Package stats provides mergeable streaming summaries for sensor readings.
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	// DefaultRelativeAccuracy bounds the relative error of quantile estimates.
+	DefaultRelativeAccuracy = 0.01
+
+	// minIndexableValue is the smallest magnitude tracked by the log buckets; anything below counts as zero.
+	minIndexableValue = 1e-9
+)
+
+// DDSketch is a quantile sketch with relative-error guarantees (Masson et al., VLDB 2019).
+// Values are mapped to logarithmically sized buckets, so two sketches with the same accuracy
+// merge exactly by summing their bucket counts.
+type DDSketch struct {
+	Alpha    float64        `json:"alpha"`
+	Positive map[int]uint64 `json:"pos,omitempty"`
+	Negative map[int]uint64 `json:"neg,omitempty"`
+	Zero     uint64         `json:"zero,omitempty"`
+	Count    uint64         `json:"count"`
+
+	gamma      float64
+	multiplier float64
+}
+
+// NewDDSketch returns an empty sketch with the given relative accuracy.
+func NewDDSketch(alpha float64) *DDSketch {
+	s := &DDSketch{
+		Alpha:    alpha,
+		Positive: map[int]uint64{},
+		Negative: map[int]uint64{},
+	}
+	s.init()
+	return s
+}
+
+func (s *DDSketch) init() {
+	if s.gamma != 0 {
+		return
+	}
+	s.gamma = (1 + s.Alpha) / (1 - s.Alpha)
+	s.multiplier = 1 / math.Log(s.gamma)
+	if s.Positive == nil {
+		s.Positive = map[int]uint64{}
+	}
+	if s.Negative == nil {
+		s.Negative = map[int]uint64{}
+	}
+}
+
+func (s *DDSketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) * s.multiplier))
+}
+
+func (s *DDSketch) value(i int) float64 {
+	return 2 * math.Pow(s.gamma, float64(i)) / (s.gamma + 1)
+}
+
+// Add records a single value.
+func (s *DDSketch) Add(v float64) {
+	s.init()
+
+	switch {
+	case v > minIndexableValue:
+		s.Positive[s.index(v)]++
+	case v < -minIndexableValue:
+		s.Negative[s.index(-v)]++
+	default:
+		s.Zero++
+	}
+	s.Count++
+}
+
+// Merge folds o into s. Both sketches must share the same accuracy.
+func (s *DDSketch) Merge(o *DDSketch) {
+	s.init()
+
+	for i, c := range o.Positive {
+		s.Positive[i] += c
+	}
+	for i, c := range o.Negative {
+		s.Negative[i] += c
+	}
+	s.Zero += o.Zero
+	s.Count += o.Count
+}
+
+// Quantile returns an estimate of the q-quantile, q in [0, 1].
+func (s *DDSketch) Quantile(q float64) float64 {
+	s.init()
+
+	if s.Count == 0 {
+		return math.NaN()
+	}
+
+	rank := uint64(q * float64(s.Count-1))
+	var seen uint64
+
+	// Most negative values live in the highest negative indexes
+	neg := sortedKeys(s.Negative)
+	for i := len(neg) - 1; i >= 0; i-- {
+		seen += s.Negative[neg[i]]
+		if seen > rank {
+			return -s.value(neg[i])
+		}
+	}
+
+	seen += s.Zero
+	if seen > rank {
+		return 0
+	}
+
+	for _, i := range sortedKeys(s.Positive) {
+		seen += s.Positive[i]
+		if seen > rank {
+			return s.value(i)
+		}
+	}
+
+	return math.NaN()
+}
+
+func sortedKeys(m map[int]uint64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
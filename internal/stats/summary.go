@@ -0,0 +1,77 @@
+package stats
+
+import "math"
+
+// Summary accumulates count, extrema, moments and a quantile sketch over a stream of values.
+// Summaries of disjoint ranges can be merged, which lets per-day partials be cached and combined.
+type Summary struct {
+	Count  int       `json:"count"`
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Mean   float64   `json:"mean"`
+	M2     float64   `json:"m2"`
+	Sketch *DDSketch `json:"sketch"`
+}
+
+func NewSummary() *Summary {
+	return &Summary{
+		Sketch: NewDDSketch(DefaultRelativeAccuracy),
+	}
+}
+
+// Add records a single value using Welford's online update.
+func (s *Summary) Add(v float64) {
+	if s.Count == 0 {
+		s.Min, s.Max = v, v
+	} else {
+		s.Min = min(s.Min, v)
+		s.Max = max(s.Max, v)
+	}
+
+	s.Count++
+	delta := v - s.Mean
+	s.Mean += delta / float64(s.Count)
+	s.M2 += delta * (v - s.Mean)
+	s.Sketch.Add(v)
+}
+
+// Merge folds o into s using Chan's parallel variance formula.
+func (s *Summary) Merge(o *Summary) {
+	if o == nil || o.Count == 0 {
+		return
+	}
+	if s.Count == 0 {
+		s.Min, s.Max = o.Min, o.Max
+	} else {
+		s.Min = min(s.Min, o.Min)
+		s.Max = max(s.Max, o.Max)
+	}
+
+	n := float64(s.Count + o.Count)
+	delta := o.Mean - s.Mean
+	s.M2 += o.M2 + delta*delta*float64(s.Count)*float64(o.Count)/n
+	s.Mean += delta * float64(o.Count) / n
+	s.Count += o.Count
+	s.Sketch.Merge(o.Sketch)
+}
+
+// Variance returns the population variance.
+func (s *Summary) Variance() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.M2 / float64(s.Count)
+}
+
+// StdDev returns the population standard deviation.
+func (s *Summary) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Quantile returns the q-quantile estimate, clamped to the observed extrema.
+func (s *Summary) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return min(max(s.Sketch.Quantile(q), s.Min), s.Max)
+}
@@ -0,0 +1,119 @@
+// Package stream fans readings out to live subscribers.
+package stream
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+// Subscription receives the readings of a set of sensors. A subscriber that falls more
+// than its buffer behind is dropped: Lagged is closed and no more readings are sent, so
+// the client can reconnect and resume from the cache instead of silently losing data.
+type Subscription struct {
+	C <-chan types.Reading
+
+	ch      chan types.Reading
+	sensors map[uuid.UUID]struct{}
+	lagged  chan struct{}
+	once    sync.Once
+}
+
+// Lagged is closed once the subscriber has been dropped for falling behind.
+func (s *Subscription) Lagged() <-chan struct{} {
+	return s.lagged
+}
+
+func (s *Subscription) drop() {
+	s.once.Do(func() {
+		close(s.lagged)
+		metrics.StreamLaggedTotal.Inc()
+	})
+}
+
+// Hub fans readings published once out to every subscription interested in the sensor.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers interest in sensors. buffer bounds how many readings may be
+// queued for the subscriber before it is dropped.
+func (h *Hub) Subscribe(sensors []uuid.UUID, buffer int) *Subscription {
+	ch := make(chan types.Reading, buffer)
+	sub := &Subscription{
+		C:       ch,
+		ch:      ch,
+		sensors: make(map[uuid.UUID]struct{}),
+		lagged:  make(chan struct{}),
+	}
+
+	h.Add(sub, sensors...)
+	return sub
+}
+
+// Add extends an existing subscription with more sensors.
+func (h *Hub) Add(sub *Subscription, sensors ...uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, id := range sensors {
+		if h.subs[id] == nil {
+			h.subs[id] = make(map[*Subscription]struct{})
+		}
+		h.subs[id][sub] = struct{}{}
+		sub.sensors[id] = struct{}{}
+	}
+}
+
+// Remove stops delivering readings of sensors to sub.
+func (h *Hub) Remove(sub *Subscription, sensors ...uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub, sensors)
+}
+
+// remove must be called with mu held.
+func (h *Hub) remove(sub *Subscription, sensors []uuid.UUID) {
+	for _, id := range sensors {
+		delete(h.subs[id], sub)
+		if len(h.subs[id]) == 0 {
+			delete(h.subs, id)
+		}
+		delete(sub.sensors, id)
+	}
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub, slices.Collect(maps.Keys(sub.sensors)))
+}
+
+// Publish never blocks; subscribers with a full buffer are dropped.
+func (h *Hub) Publish(r types.Reading) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs[r.SensorID] {
+		select {
+		case <-sub.lagged:
+		case sub.ch <- r:
+		default:
+			sub.drop()
+		}
+	}
+}
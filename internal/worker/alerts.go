@@ -0,0 +1,279 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/metrics"
+	"github.com/ntentasd/nostradamus-api/pkg/types"
+)
+
+// alertReadingsBuffer is how many readings may queue up before new ones are dropped.
+const alertReadingsBuffer = 4096
+
+type alertKey struct {
+	ruleID   uuid.UUID
+	sensorID uuid.UUID
+}
+
+// alertState is the evaluation state of a rule for one sensor.
+type alertState struct {
+	firing bool
+	// breachedSince is the timestamp of the first reading of the current breach, while
+	// the rule waits out its For duration
+	breachedSince time.Time
+}
+
+// AlertEvaluator evaluates alert rules against incoming readings. A rule fires for a
+// sensor once readings breach its threshold for the rule's For duration, and resolves
+// once they are back past the threshold by its hysteresis. Transitions are persisted to
+// the alert history and passed on to the notify hook.
+type AlertEvaluator struct {
+	Store     *db.DB
+	Interval  time.Duration
+	readings  chan types.Reading
+	notify    func(types.AlertRule, types.AlertEvent)
+	cancelCtx context.CancelFunc
+	logger    zerolog.Logger
+
+	// The fields below are only touched by the evaluation goroutine
+	rules        map[uuid.UUID]types.AlertRule
+	bySensor     map[uuid.UUID][]uuid.UUID
+	byField      map[uuid.UUID][]uuid.UUID
+	sensorFields map[uuid.UUID]uuid.UUID
+	states       map[alertKey]*alertState
+}
+
+// NewAlertEvaluator creates a new background worker for alert evaluation. Rules and
+// sensors are reloaded every interval. notify, if set, is called with every transition
+// and must not block.
+func NewAlertEvaluator(store *db.DB, interval time.Duration, notify func(types.AlertRule, types.AlertEvent), logger zerolog.Logger) *AlertEvaluator {
+	return &AlertEvaluator{
+		Store:    store,
+		Interval: interval,
+		readings: make(chan types.Reading, alertReadingsBuffer),
+		notify:   notify,
+		logger:   logger,
+		rules:    make(map[uuid.UUID]types.AlertRule),
+		states:   make(map[alertKey]*alertState),
+	}
+}
+
+// Observe queues a reading for evaluation. It never blocks; readings arriving while the
+// queue is full are dropped.
+func (e *AlertEvaluator) Observe(r types.Reading) {
+	select {
+	case e.readings <- r:
+	default:
+		metrics.AlertReadingsDroppedTotal.Inc()
+	}
+}
+
+func (e *AlertEvaluator) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	e.cancelCtx = cancel
+
+	go func() {
+		ticker := time.NewTicker(e.Interval)
+		defer ticker.Stop()
+
+		if err := e.restore(ctx); err != nil {
+			e.logger.Warn().Err(err).Msg("failed to restore firing alerts")
+		}
+		if err := e.reload(ctx); err != nil {
+			e.logger.Warn().Err(err).Msg("failed to load alert rules")
+		}
+
+		e.logger.Info().Msg("alert evaluation started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				e.logger.Info().Msg("alert evaluation stopped")
+				return
+			case <-ticker.C:
+				if err := e.reload(ctx); err != nil {
+					e.logger.Warn().Err(err).Msg("failed to reload alert rules")
+				}
+			case r := <-e.readings:
+				e.evaluate(ctx, r)
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the background worker.
+func (e *AlertEvaluator) Stop() {
+	if e.cancelCtx != nil {
+		e.cancelCtx()
+	}
+}
+
+// restore marks the alerts persisted as firing, so a restart does not fire them again.
+func (e *AlertEvaluator) restore(ctx context.Context) error {
+	firing, err := e.Store.ListFiringAlerts(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range firing {
+		e.states[alertKey{f.RuleID, f.SensorID}] = &alertState{firing: true}
+	}
+	metrics.AlertsFiring.Set(float64(len(firing)))
+
+	return nil
+}
+
+// reload refreshes the rules and the sensor to field mapping. The state of rules that
+// were removed, disabled or changed since the last reload is dropped.
+func (e *AlertEvaluator) reload(ctx context.Context) error {
+	rules, err := e.Store.ListAllAlertRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := e.Store.ListSensorAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := make(map[uuid.UUID]types.AlertRule, len(rules))
+	bySensor := make(map[uuid.UUID][]uuid.UUID)
+	byField := make(map[uuid.UUID][]uuid.UUID)
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		next[rule.RuleID] = rule
+
+		if rule.SensorID != nil {
+			bySensor[*rule.SensorID] = append(bySensor[*rule.SensorID], rule.RuleID)
+		} else {
+			byField[rule.FieldID] = append(byField[rule.FieldID], rule.RuleID)
+		}
+	}
+
+	sensorFields := make(map[uuid.UUID]uuid.UUID, len(accounts))
+	for _, a := range accounts {
+		if !a.Decommissioned {
+			sensorFields[a.SensorID] = a.FieldID
+		}
+	}
+
+	firing := 0
+	for key, state := range e.states {
+		prev, known := e.rules[key.ruleID]
+		rule, ok := next[key.ruleID]
+		// States restored before the first load have no previous rule to compare with
+		if !ok || (known && !rule.UpdatedAt.Equal(prev.UpdatedAt)) {
+			delete(e.states, key)
+			continue
+		}
+		if state.firing {
+			firing++
+		}
+	}
+	metrics.AlertsFiring.Set(float64(firing))
+
+	e.rules = next
+	e.bySensor = bySensor
+	e.byField = byField
+	e.sensorFields = sensorFields
+
+	return nil
+}
+
+// evaluate runs every rule covering the sensor of r.
+func (e *AlertEvaluator) evaluate(ctx context.Context, r types.Reading) {
+	ruleIDs := e.bySensor[r.SensorID]
+	if fieldID, ok := e.sensorFields[r.SensorID]; ok && len(e.byField[fieldID]) > 0 {
+		ruleIDs = append(append([]uuid.UUID{}, ruleIDs...), e.byField[fieldID]...)
+	}
+
+	for _, ruleID := range ruleIDs {
+		rule := e.rules[ruleID]
+		if rule.SensorType != r.SensorType {
+			continue
+		}
+
+		key := alertKey{ruleID, r.SensorID}
+		state := e.states[key]
+		if state == nil {
+			state = &alertState{}
+		}
+
+		switch {
+		case state.firing:
+			if rule.Cleared(r.Value) {
+				state.firing = false
+				e.transition(ctx, rule, r, types.AlertStateResolved)
+			}
+		case rule.Breached(r.Value):
+			if state.breachedSince.IsZero() {
+				state.breachedSince = r.Timestamp
+			}
+			if r.Timestamp.Sub(state.breachedSince) >= time.Duration(rule.For) {
+				state.firing = true
+				state.breachedSince = time.Time{}
+				e.transition(ctx, rule, r, types.AlertStateFiring)
+			}
+		default:
+			state.breachedSince = time.Time{}
+		}
+
+		// Only firing or pending states are worth keeping
+		if state.firing || !state.breachedSince.IsZero() {
+			e.states[key] = state
+		} else {
+			delete(e.states, key)
+		}
+	}
+}
+
+func (e *AlertEvaluator) transition(ctx context.Context, rule types.AlertRule, r types.Reading, state types.AlertState) {
+	fieldID := rule.FieldID
+	if id, ok := e.sensorFields[r.SensorID]; ok {
+		fieldID = id
+	}
+
+	event := types.AlertEvent{
+		EventID:    uuid.UUID(gocql.UUIDFromTime(r.Timestamp)),
+		RuleID:     rule.RuleID,
+		SensorID:   r.SensorID,
+		FieldID:    fieldID,
+		State:      state,
+		Severity:   rule.Severity,
+		Comparator: rule.Comparator,
+		Threshold:  rule.Threshold,
+		Value:      r.Value,
+		Timestamp:  r.Timestamp,
+	}
+
+	metrics.AlertTransitionsTotal.WithLabelValues(string(state), string(rule.Severity)).Inc()
+	if state == types.AlertStateFiring {
+		metrics.AlertsFiring.Inc()
+	} else {
+		metrics.AlertsFiring.Dec()
+	}
+
+	e.logger.Info().
+		Str("rule_id", rule.RuleID.String()).
+		Str("sensor_id", r.SensorID.String()).
+		Str("state", string(state)).
+		Str("severity", string(rule.Severity)).
+		Float64("value", r.Value).
+		Msg("alert state changed")
+
+	if err := e.Store.RecordAlertEvent(ctx, event); err != nil {
+		e.logger.Error().Err(err).Str("rule_id", rule.RuleID.String()).Msg("failed to record alert event")
+	}
+
+	if e.notify != nil {
+		e.notify(rule, event)
+	}
+}
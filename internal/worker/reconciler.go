@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/ntentasd/nostradamus-api/internal/db"
+	"github.com/ntentasd/nostradamus-api/internal/emqx"
+)
+
+// Reconciler periodically repairs drift between sensors_by_field and the EMQX user list:
+// it recreates missing users of active sensors and removes users that belong to no active sensor.
+type Reconciler struct {
+	Store     *db.DB
+	Emqx      *emqx.EmqxClient
+	Interval  time.Duration
+	cancelCtx context.CancelFunc
+	logger    zerolog.Logger
+
+	// suspects holds orphaned users seen in the previous pass. A user is only deleted once it
+	// has been orphaned for two passes in a row, so registrations in flight are left alone.
+	suspects map[string]bool
+}
+
+// NewReconciler creates a new background worker for MQTT user reconciliation.
+func NewReconciler(store *db.DB, ec *emqx.EmqxClient, interval time.Duration, logger zerolog.Logger) *Reconciler {
+	return &Reconciler{
+		Store:    store,
+		Emqx:     ec,
+		Interval: interval,
+		logger:   logger,
+		suspects: make(map[string]bool),
+	}
+}
+
+func (rc *Reconciler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	rc.cancelCtx = cancel
+
+	go func() {
+		ticker := time.NewTicker(rc.Interval)
+		defer ticker.Stop()
+
+		rc.logger.Info().Msg("mqtt user reconciliation started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				rc.logger.Info().Msg("mqtt user reconciliation stopped")
+				return
+			case <-ticker.C:
+				if err := rc.reconcile(ctx); err != nil {
+					rc.logger.Warn().Err(err).Msg("failed to reconcile mqtt users")
+				}
+			}
+		}
+	}()
+}
+
+// Stop gracefully stops the background worker.
+func (rc *Reconciler) Stop() {
+	if rc.cancelCtx != nil {
+		rc.cancelCtx()
+	}
+}
+
+// reconcile runs a single pass over both sides.
+func (rc *Reconciler) reconcile(ctx context.Context) error {
+	accounts, err := rc.Store.ListSensorAccounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	users, err := rc.Emqx.ListUsers()
+	if err != nil {
+		return err
+	}
+
+	inEmqx := make(map[string]bool, len(users))
+	for _, u := range users {
+		inEmqx[u.UserID] = true
+	}
+
+	active := make(map[string]bool, len(accounts))
+	for _, a := range accounts {
+		if a.MqttUser == "" || a.Decommissioned {
+			continue
+		}
+		active[a.MqttUser] = true
+
+		if inEmqx[a.MqttUser] {
+			continue
+		}
+
+		// Active sensor without a broker user, recreate it with the stored password
+		creds, err := rc.Store.GetActiveSensorCredentials(a.FieldID, a.SensorID)
+		if err != nil || creds.MqttPass == "" {
+			rc.logger.Warn().Err(err).Str("sensor_id", a.SensorID.String()).Msg("cannot restore EMQX user, no stored password")
+			continue
+		}
+		if _, err := rc.Emqx.CreateUser(creds.MqttUser, creds.MqttPass, false); err != nil {
+			rc.logger.Error().Err(err).Str("username", creds.MqttUser).Msg("failed to restore EMQX user")
+			continue
+		}
+		rc.logger.Info().Str("username", creds.MqttUser).Str("sensor_id", a.SensorID.String()).Msg("restored missing EMQX user")
+	}
+
+	suspects := make(map[string]bool)
+	for _, u := range users {
+		if u.IsSuperuser || active[u.UserID] || !emqx.IsSensorUsername(u.UserID) {
+			continue
+		}
+
+		if !rc.suspects[u.UserID] {
+			suspects[u.UserID] = true
+			continue
+		}
+
+		if err := rc.Emqx.DeleteUser(u.UserID); err != nil {
+			rc.logger.Error().Err(err).Str("username", u.UserID).Msg("failed to delete orphaned EMQX user")
+			suspects[u.UserID] = true
+			continue
+		}
+		rc.logger.Info().Str("username", u.UserID).Msg("deleted orphaned EMQX user")
+	}
+	rc.suspects = suspects
+
+	return nil
+}
@@ -0,0 +1,1150 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: proto/sensor.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SensorType int32
+
+const (
+	SensorType_SENSOR_TYPE_UNSPECIFIED SensorType = 0
+	SensorType_SENSOR_TYPE_TEMPERATURE SensorType = 1
+	SensorType_SENSOR_TYPE_HUMIDITY    SensorType = 2
+	SensorType_SENSOR_TYPE_PH_LEVEL    SensorType = 3
+)
+
+// Enum value maps for SensorType.
+var (
+	SensorType_name = map[int32]string{
+		0: "SENSOR_TYPE_UNSPECIFIED",
+		1: "SENSOR_TYPE_TEMPERATURE",
+		2: "SENSOR_TYPE_HUMIDITY",
+		3: "SENSOR_TYPE_PH_LEVEL",
+	}
+	SensorType_value = map[string]int32{
+		"SENSOR_TYPE_UNSPECIFIED": 0,
+		"SENSOR_TYPE_TEMPERATURE": 1,
+		"SENSOR_TYPE_HUMIDITY":    2,
+		"SENSOR_TYPE_PH_LEVEL":    3,
+	}
+)
+
+func (x SensorType) Enum() *SensorType {
+	p := new(SensorType)
+	*p = x
+	return p
+}
+
+func (x SensorType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SensorType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_sensor_proto_enumTypes[0].Descriptor()
+}
+
+func (SensorType) Type() protoreflect.EnumType {
+	return &file_proto_sensor_proto_enumTypes[0]
+}
+
+func (x SensorType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SensorType.Descriptor instead.
+func (SensorType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{0}
+}
+
+type Field struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FieldId       string                 `protobuf:"bytes,1,opt,name=field_id,json=fieldId,proto3" json:"field_id,omitempty"`
+	FieldName     string                 `protobuf:"bytes,2,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Field) Reset() {
+	*x = Field{}
+	mi := &file_proto_sensor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Field) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Field) ProtoMessage() {}
+
+func (x *Field) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Field.ProtoReflect.Descriptor instead.
+func (*Field) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Field) GetFieldId() string {
+	if x != nil {
+		return x.FieldId
+	}
+	return ""
+}
+
+func (x *Field) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+func (x *Field) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type Sensor struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	SensorId   string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	SensorName string                 `protobuf:"bytes,2,opt,name=sensor_name,json=sensorName,proto3" json:"sensor_name,omitempty"`
+	SensorType SensorType             `protobuf:"varint,3,opt,name=sensor_type,json=sensorType,proto3,enum=nostradamus.v1.SensorType" json:"sensor_type,omitempty"`
+	FieldId    string                 `protobuf:"bytes,4,opt,name=field_id,json=fieldId,proto3" json:"field_id,omitempty"`
+	FieldName  string                 `protobuf:"bytes,5,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"`
+	// Set once the sensor is retired; its readings stay queryable.
+	DecommissionedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=decommissioned_at,json=decommissionedAt,proto3" json:"decommissioned_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Sensor) Reset() {
+	*x = Sensor{}
+	mi := &file_proto_sensor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sensor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sensor) ProtoMessage() {}
+
+func (x *Sensor) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sensor.ProtoReflect.Descriptor instead.
+func (*Sensor) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Sensor) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *Sensor) GetSensorName() string {
+	if x != nil {
+		return x.SensorName
+	}
+	return ""
+}
+
+func (x *Sensor) GetSensorType() SensorType {
+	if x != nil {
+		return x.SensorType
+	}
+	return SensorType_SENSOR_TYPE_UNSPECIFIED
+}
+
+func (x *Sensor) GetFieldId() string {
+	if x != nil {
+		return x.FieldId
+	}
+	return ""
+}
+
+func (x *Sensor) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+func (x *Sensor) GetDecommissionedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DecommissionedAt
+	}
+	return nil
+}
+
+type Entry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value         float64                `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	mi := &file_proto_sensor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Entry) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Entry) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type Reading struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	SensorType    SensorType             `protobuf:"varint,2,opt,name=sensor_type,json=sensorType,proto3,enum=nostradamus.v1.SensorType" json:"sensor_type,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Value         float64                `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reading) Reset() {
+	*x = Reading{}
+	mi := &file_proto_sensor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reading) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reading) ProtoMessage() {}
+
+func (x *Reading) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reading.ProtoReflect.Descriptor instead.
+func (*Reading) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Reading) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *Reading) GetSensorType() SensorType {
+	if x != nil {
+		return x.SensorType
+	}
+	return SensorType_SENSOR_TYPE_UNSPECIFIED
+}
+
+func (x *Reading) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Reading) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type Aggregate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Avg           float64                `protobuf:"fixed64,1,opt,name=avg,proto3" json:"avg,omitempty"`
+	Min           float64                `protobuf:"fixed64,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,3,opt,name=max,proto3" json:"max,omitempty"`
+	Count         int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	Median        *float64               `protobuf:"fixed64,5,opt,name=median,proto3,oneof" json:"median,omitempty"`
+	Stddev        *float64               `protobuf:"fixed64,6,opt,name=stddev,proto3,oneof" json:"stddev,omitempty"`
+	Variance      *float64               `protobuf:"fixed64,7,opt,name=variance,proto3,oneof" json:"variance,omitempty"`
+	Percentiles   map[string]float64     `protobuf:"bytes,8,rep,name=percentiles,proto3" json:"percentiles,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Aggregate) Reset() {
+	*x = Aggregate{}
+	mi := &file_proto_sensor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Aggregate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Aggregate) ProtoMessage() {}
+
+func (x *Aggregate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Aggregate.ProtoReflect.Descriptor instead.
+func (*Aggregate) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Aggregate) GetAvg() float64 {
+	if x != nil {
+		return x.Avg
+	}
+	return 0
+}
+
+func (x *Aggregate) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *Aggregate) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *Aggregate) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Aggregate) GetMedian() float64 {
+	if x != nil && x.Median != nil {
+		return *x.Median
+	}
+	return 0
+}
+
+func (x *Aggregate) GetStddev() float64 {
+	if x != nil && x.Stddev != nil {
+		return *x.Stddev
+	}
+	return 0
+}
+
+func (x *Aggregate) GetVariance() float64 {
+	if x != nil && x.Variance != nil {
+		return *x.Variance
+	}
+	return 0
+}
+
+func (x *Aggregate) GetPercentiles() map[string]float64 {
+	if x != nil {
+		return x.Percentiles
+	}
+	return nil
+}
+
+func (x *Aggregate) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type ListFieldsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFieldsRequest) Reset() {
+	*x = ListFieldsRequest{}
+	mi := &file_proto_sensor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFieldsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFieldsRequest) ProtoMessage() {}
+
+func (x *ListFieldsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFieldsRequest.ProtoReflect.Descriptor instead.
+func (*ListFieldsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{5}
+}
+
+type ListFieldsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fields        []*Field               `protobuf:"bytes,1,rep,name=fields,proto3" json:"fields,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFieldsResponse) Reset() {
+	*x = ListFieldsResponse{}
+	mi := &file_proto_sensor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFieldsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFieldsResponse) ProtoMessage() {}
+
+func (x *ListFieldsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFieldsResponse.ProtoReflect.Descriptor instead.
+func (*ListFieldsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListFieldsResponse) GetFields() []*Field {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+type RegisterSensorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FieldId       string                 `protobuf:"bytes,1,opt,name=field_id,json=fieldId,proto3" json:"field_id,omitempty"`
+	SensorName    string                 `protobuf:"bytes,2,opt,name=sensor_name,json=sensorName,proto3" json:"sensor_name,omitempty"`
+	SensorType    SensorType             `protobuf:"varint,3,opt,name=sensor_type,json=sensorType,proto3,enum=nostradamus.v1.SensorType" json:"sensor_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSensorRequest) Reset() {
+	*x = RegisterSensorRequest{}
+	mi := &file_proto_sensor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSensorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSensorRequest) ProtoMessage() {}
+
+func (x *RegisterSensorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSensorRequest.ProtoReflect.Descriptor instead.
+func (*RegisterSensorRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RegisterSensorRequest) GetFieldId() string {
+	if x != nil {
+		return x.FieldId
+	}
+	return ""
+}
+
+func (x *RegisterSensorRequest) GetSensorName() string {
+	if x != nil {
+		return x.SensorName
+	}
+	return ""
+}
+
+func (x *RegisterSensorRequest) GetSensorType() SensorType {
+	if x != nil {
+		return x.SensorType
+	}
+	return SensorType_SENSOR_TYPE_UNSPECIFIED
+}
+
+type RegisterSensorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensor        *Sensor                `protobuf:"bytes,1,opt,name=sensor,proto3" json:"sensor,omitempty"`
+	MqttUser      string                 `protobuf:"bytes,2,opt,name=mqtt_user,json=mqttUser,proto3" json:"mqtt_user,omitempty"`
+	MqttPass      string                 `protobuf:"bytes,3,opt,name=mqtt_pass,json=mqttPass,proto3" json:"mqtt_pass,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSensorResponse) Reset() {
+	*x = RegisterSensorResponse{}
+	mi := &file_proto_sensor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSensorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSensorResponse) ProtoMessage() {}
+
+func (x *RegisterSensorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSensorResponse.ProtoReflect.Descriptor instead.
+func (*RegisterSensorResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RegisterSensorResponse) GetSensor() *Sensor {
+	if x != nil {
+		return x.Sensor
+	}
+	return nil
+}
+
+func (x *RegisterSensorResponse) GetMqttUser() string {
+	if x != nil {
+		return x.MqttUser
+	}
+	return ""
+}
+
+func (x *RegisterSensorResponse) GetMqttPass() string {
+	if x != nil {
+		return x.MqttPass
+	}
+	return ""
+}
+
+type GetLatestRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SensorId string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	// Defaults to the REST default when zero.
+	N int32 `protobuf:"varint,2,opt,name=n,proto3" json:"n,omitempty"`
+	// Looked up when unspecified.
+	SensorType    SensorType `protobuf:"varint,3,opt,name=sensor_type,json=sensorType,proto3,enum=nostradamus.v1.SensorType" json:"sensor_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatestRequest) Reset() {
+	*x = GetLatestRequest{}
+	mi := &file_proto_sensor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestRequest) ProtoMessage() {}
+
+func (x *GetLatestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestRequest.ProtoReflect.Descriptor instead.
+func (*GetLatestRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetLatestRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *GetLatestRequest) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *GetLatestRequest) GetSensorType() SensorType {
+	if x != nil {
+		return x.SensorType
+	}
+	return SensorType_SENSOR_TYPE_UNSPECIFIED
+}
+
+type GetLatestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*Entry               `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatestResponse) Reset() {
+	*x = GetLatestResponse{}
+	mi := &file_proto_sensor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestResponse) ProtoMessage() {}
+
+func (x *GetLatestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestResponse.ProtoReflect.Descriptor instead.
+func (*GetLatestResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetLatestResponse) GetEntries() []*Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type GetAggregateRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SensorId string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	// Looked up when unspecified.
+	SensorType SensorType           `protobuf:"varint,2,opt,name=sensor_type,json=sensorType,proto3,enum=nostradamus.v1.SensorType" json:"sensor_type,omitempty"`
+	Window     *durationpb.Duration `protobuf:"bytes,3,opt,name=window,proto3" json:"window,omitempty"`
+	// Optional statistics, as accepted by the stats query param of /aggregate.
+	Stats         []string `protobuf:"bytes,4,rep,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAggregateRequest) Reset() {
+	*x = GetAggregateRequest{}
+	mi := &file_proto_sensor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregateRequest) ProtoMessage() {}
+
+func (x *GetAggregateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregateRequest.ProtoReflect.Descriptor instead.
+func (*GetAggregateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetAggregateRequest) GetSensorId() string {
+	if x != nil {
+		return x.SensorId
+	}
+	return ""
+}
+
+func (x *GetAggregateRequest) GetSensorType() SensorType {
+	if x != nil {
+		return x.SensorType
+	}
+	return SensorType_SENSOR_TYPE_UNSPECIFIED
+}
+
+func (x *GetAggregateRequest) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *GetAggregateRequest) GetStats() []string {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type GetAggregateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Aggregate     *Aggregate             `protobuf:"bytes,1,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAggregateResponse) Reset() {
+	*x = GetAggregateResponse{}
+	mi := &file_proto_sensor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAggregateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAggregateResponse) ProtoMessage() {}
+
+func (x *GetAggregateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAggregateResponse.ProtoReflect.Descriptor instead.
+func (*GetAggregateResponse) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetAggregateResponse) GetAggregate() *Aggregate {
+	if x != nil {
+		return x.Aggregate
+	}
+	return nil
+}
+
+type StreamReadingsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Target:
+	//
+	//	*StreamReadingsRequest_SensorId
+	//	*StreamReadingsRequest_FieldId
+	Target isStreamReadingsRequest_Target `protobuf_oneof:"target"`
+	// Replays cached readings newer than since before streaming.
+	Since         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamReadingsRequest) Reset() {
+	*x = StreamReadingsRequest{}
+	mi := &file_proto_sensor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamReadingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamReadingsRequest) ProtoMessage() {}
+
+func (x *StreamReadingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_sensor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamReadingsRequest.ProtoReflect.Descriptor instead.
+func (*StreamReadingsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_sensor_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StreamReadingsRequest) GetTarget() isStreamReadingsRequest_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *StreamReadingsRequest) GetSensorId() string {
+	if x != nil {
+		if x, ok := x.Target.(*StreamReadingsRequest_SensorId); ok {
+			return x.SensorId
+		}
+	}
+	return ""
+}
+
+func (x *StreamReadingsRequest) GetFieldId() string {
+	if x != nil {
+		if x, ok := x.Target.(*StreamReadingsRequest_FieldId); ok {
+			return x.FieldId
+		}
+	}
+	return ""
+}
+
+func (x *StreamReadingsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+type isStreamReadingsRequest_Target interface {
+	isStreamReadingsRequest_Target()
+}
+
+type StreamReadingsRequest_SensorId struct {
+	SensorId string `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3,oneof"`
+}
+
+type StreamReadingsRequest_FieldId struct {
+	FieldId string `protobuf:"bytes,2,opt,name=field_id,json=fieldId,proto3,oneof"`
+}
+
+func (*StreamReadingsRequest_SensorId) isStreamReadingsRequest_Target() {}
+
+func (*StreamReadingsRequest_FieldId) isStreamReadingsRequest_Target() {}
+
+var File_proto_sensor_proto protoreflect.FileDescriptor
+
+const file_proto_sensor_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/sensor.proto\x12\x0enostradamus.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"Z\n" +
+	"\x05Field\x12\x19\n" +
+	"\bfield_id\x18\x01 \x01(\tR\afieldId\x12\x1d\n" +
+	"\n" +
+	"field_name\x18\x02 \x01(\tR\tfieldName\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\"\x86\x02\n" +
+	"\x06Sensor\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\x1f\n" +
+	"\vsensor_name\x18\x02 \x01(\tR\n" +
+	"sensorName\x12;\n" +
+	"\vsensor_type\x18\x03 \x01(\x0e2\x1a.nostradamus.v1.SensorTypeR\n" +
+	"sensorType\x12\x19\n" +
+	"\bfield_id\x18\x04 \x01(\tR\afieldId\x12\x1d\n" +
+	"\n" +
+	"field_name\x18\x05 \x01(\tR\tfieldName\x12G\n" +
+	"\x11decommissioned_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x10decommissionedAt\"W\n" +
+	"\x05Entry\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value\"\xb3\x01\n" +
+	"\aReading\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12;\n" +
+	"\vsensor_type\x18\x02 \x01(\x0e2\x1a.nostradamus.v1.SensorTypeR\n" +
+	"sensorType\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x01R\x05value\"\x9d\x03\n" +
+	"\tAggregate\x12\x10\n" +
+	"\x03avg\x18\x01 \x01(\x01R\x03avg\x12\x10\n" +
+	"\x03min\x18\x02 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\x01R\x03max\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\x12\x1b\n" +
+	"\x06median\x18\x05 \x01(\x01H\x00R\x06median\x88\x01\x01\x12\x1b\n" +
+	"\x06stddev\x18\x06 \x01(\x01H\x01R\x06stddev\x88\x01\x01\x12\x1f\n" +
+	"\bvariance\x18\a \x01(\x01H\x02R\bvariance\x88\x01\x01\x12L\n" +
+	"\vpercentiles\x18\b \x03(\v2*.nostradamus.v1.Aggregate.PercentilesEntryR\vpercentiles\x128\n" +
+	"\ttimestamp\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1a>\n" +
+	"\x10PercentilesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01B\t\n" +
+	"\a_medianB\t\n" +
+	"\a_stddevB\v\n" +
+	"\t_variance\"\x13\n" +
+	"\x11ListFieldsRequest\"C\n" +
+	"\x12ListFieldsResponse\x12-\n" +
+	"\x06fields\x18\x01 \x03(\v2\x15.nostradamus.v1.FieldR\x06fields\"\x90\x01\n" +
+	"\x15RegisterSensorRequest\x12\x19\n" +
+	"\bfield_id\x18\x01 \x01(\tR\afieldId\x12\x1f\n" +
+	"\vsensor_name\x18\x02 \x01(\tR\n" +
+	"sensorName\x12;\n" +
+	"\vsensor_type\x18\x03 \x01(\x0e2\x1a.nostradamus.v1.SensorTypeR\n" +
+	"sensorType\"\x82\x01\n" +
+	"\x16RegisterSensorResponse\x12.\n" +
+	"\x06sensor\x18\x01 \x01(\v2\x16.nostradamus.v1.SensorR\x06sensor\x12\x1b\n" +
+	"\tmqtt_user\x18\x02 \x01(\tR\bmqttUser\x12\x1b\n" +
+	"\tmqtt_pass\x18\x03 \x01(\tR\bmqttPass\"z\n" +
+	"\x10GetLatestRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\f\n" +
+	"\x01n\x18\x02 \x01(\x05R\x01n\x12;\n" +
+	"\vsensor_type\x18\x03 \x01(\x0e2\x1a.nostradamus.v1.SensorTypeR\n" +
+	"sensorType\"D\n" +
+	"\x11GetLatestResponse\x12/\n" +
+	"\aentries\x18\x01 \x03(\v2\x15.nostradamus.v1.EntryR\aentries\"\xb8\x01\n" +
+	"\x13GetAggregateRequest\x12\x1b\n" +
+	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12;\n" +
+	"\vsensor_type\x18\x02 \x01(\x0e2\x1a.nostradamus.v1.SensorTypeR\n" +
+	"sensorType\x121\n" +
+	"\x06window\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x06window\x12\x14\n" +
+	"\x05stats\x18\x04 \x03(\tR\x05stats\"O\n" +
+	"\x14GetAggregateResponse\x127\n" +
+	"\taggregate\x18\x01 \x01(\v2\x19.nostradamus.v1.AggregateR\taggregate\"\x8f\x01\n" +
+	"\x15StreamReadingsRequest\x12\x1d\n" +
+	"\tsensor_id\x18\x01 \x01(\tH\x00R\bsensorId\x12\x1b\n" +
+	"\bfield_id\x18\x02 \x01(\tH\x00R\afieldId\x120\n" +
+	"\x05since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05sinceB\b\n" +
+	"\x06target*z\n" +
+	"\n" +
+	"SensorType\x12\x1b\n" +
+	"\x17SENSOR_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17SENSOR_TYPE_TEMPERATURE\x10\x01\x12\x18\n" +
+	"\x14SENSOR_TYPE_HUMIDITY\x10\x02\x12\x18\n" +
+	"\x14SENSOR_TYPE_PH_LEVEL\x10\x032\xc6\x03\n" +
+	"\rSensorService\x12S\n" +
+	"\n" +
+	"ListFields\x12!.nostradamus.v1.ListFieldsRequest\x1a\".nostradamus.v1.ListFieldsResponse\x12_\n" +
+	"\x0eRegisterSensor\x12%.nostradamus.v1.RegisterSensorRequest\x1a&.nostradamus.v1.RegisterSensorResponse\x12P\n" +
+	"\tGetLatest\x12 .nostradamus.v1.GetLatestRequest\x1a!.nostradamus.v1.GetLatestResponse\x12Y\n" +
+	"\fGetAggregate\x12#.nostradamus.v1.GetAggregateRequest\x1a$.nostradamus.v1.GetAggregateResponse\x12R\n" +
+	"\x0eStreamReadings\x12%.nostradamus.v1.StreamReadingsRequest\x1a\x17.nostradamus.v1.Reading0\x01B/Z-github.com/ntentasd/nostradamus-api/pkg/pb;pbb\x06proto3"
+
+var (
+	file_proto_sensor_proto_rawDescOnce sync.Once
+	file_proto_sensor_proto_rawDescData []byte
+)
+
+func file_proto_sensor_proto_rawDescGZIP() []byte {
+	file_proto_sensor_proto_rawDescOnce.Do(func() {
+		file_proto_sensor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_sensor_proto_rawDesc), len(file_proto_sensor_proto_rawDesc)))
+	})
+	return file_proto_sensor_proto_rawDescData
+}
+
+var file_proto_sensor_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_sensor_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_proto_sensor_proto_goTypes = []any{
+	(SensorType)(0),                // 0: nostradamus.v1.SensorType
+	(*Field)(nil),                  // 1: nostradamus.v1.Field
+	(*Sensor)(nil),                 // 2: nostradamus.v1.Sensor
+	(*Entry)(nil),                  // 3: nostradamus.v1.Entry
+	(*Reading)(nil),                // 4: nostradamus.v1.Reading
+	(*Aggregate)(nil),              // 5: nostradamus.v1.Aggregate
+	(*ListFieldsRequest)(nil),      // 6: nostradamus.v1.ListFieldsRequest
+	(*ListFieldsResponse)(nil),     // 7: nostradamus.v1.ListFieldsResponse
+	(*RegisterSensorRequest)(nil),  // 8: nostradamus.v1.RegisterSensorRequest
+	(*RegisterSensorResponse)(nil), // 9: nostradamus.v1.RegisterSensorResponse
+	(*GetLatestRequest)(nil),       // 10: nostradamus.v1.GetLatestRequest
+	(*GetLatestResponse)(nil),      // 11: nostradamus.v1.GetLatestResponse
+	(*GetAggregateRequest)(nil),    // 12: nostradamus.v1.GetAggregateRequest
+	(*GetAggregateResponse)(nil),   // 13: nostradamus.v1.GetAggregateResponse
+	(*StreamReadingsRequest)(nil),  // 14: nostradamus.v1.StreamReadingsRequest
+	nil,                            // 15: nostradamus.v1.Aggregate.PercentilesEntry
+	(*timestamppb.Timestamp)(nil),  // 16: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 17: google.protobuf.Duration
+}
+var file_proto_sensor_proto_depIdxs = []int32{
+	0,  // 0: nostradamus.v1.Sensor.sensor_type:type_name -> nostradamus.v1.SensorType
+	16, // 1: nostradamus.v1.Sensor.decommissioned_at:type_name -> google.protobuf.Timestamp
+	16, // 2: nostradamus.v1.Entry.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 3: nostradamus.v1.Reading.sensor_type:type_name -> nostradamus.v1.SensorType
+	16, // 4: nostradamus.v1.Reading.timestamp:type_name -> google.protobuf.Timestamp
+	15, // 5: nostradamus.v1.Aggregate.percentiles:type_name -> nostradamus.v1.Aggregate.PercentilesEntry
+	16, // 6: nostradamus.v1.Aggregate.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 7: nostradamus.v1.ListFieldsResponse.fields:type_name -> nostradamus.v1.Field
+	0,  // 8: nostradamus.v1.RegisterSensorRequest.sensor_type:type_name -> nostradamus.v1.SensorType
+	2,  // 9: nostradamus.v1.RegisterSensorResponse.sensor:type_name -> nostradamus.v1.Sensor
+	0,  // 10: nostradamus.v1.GetLatestRequest.sensor_type:type_name -> nostradamus.v1.SensorType
+	3,  // 11: nostradamus.v1.GetLatestResponse.entries:type_name -> nostradamus.v1.Entry
+	0,  // 12: nostradamus.v1.GetAggregateRequest.sensor_type:type_name -> nostradamus.v1.SensorType
+	17, // 13: nostradamus.v1.GetAggregateRequest.window:type_name -> google.protobuf.Duration
+	5,  // 14: nostradamus.v1.GetAggregateResponse.aggregate:type_name -> nostradamus.v1.Aggregate
+	16, // 15: nostradamus.v1.StreamReadingsRequest.since:type_name -> google.protobuf.Timestamp
+	6,  // 16: nostradamus.v1.SensorService.ListFields:input_type -> nostradamus.v1.ListFieldsRequest
+	8,  // 17: nostradamus.v1.SensorService.RegisterSensor:input_type -> nostradamus.v1.RegisterSensorRequest
+	10, // 18: nostradamus.v1.SensorService.GetLatest:input_type -> nostradamus.v1.GetLatestRequest
+	12, // 19: nostradamus.v1.SensorService.GetAggregate:input_type -> nostradamus.v1.GetAggregateRequest
+	14, // 20: nostradamus.v1.SensorService.StreamReadings:input_type -> nostradamus.v1.StreamReadingsRequest
+	7,  // 21: nostradamus.v1.SensorService.ListFields:output_type -> nostradamus.v1.ListFieldsResponse
+	9,  // 22: nostradamus.v1.SensorService.RegisterSensor:output_type -> nostradamus.v1.RegisterSensorResponse
+	11, // 23: nostradamus.v1.SensorService.GetLatest:output_type -> nostradamus.v1.GetLatestResponse
+	13, // 24: nostradamus.v1.SensorService.GetAggregate:output_type -> nostradamus.v1.GetAggregateResponse
+	4,  // 25: nostradamus.v1.SensorService.StreamReadings:output_type -> nostradamus.v1.Reading
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_proto_sensor_proto_init() }
+func file_proto_sensor_proto_init() {
+	if File_proto_sensor_proto != nil {
+		return
+	}
+	file_proto_sensor_proto_msgTypes[4].OneofWrappers = []any{}
+	file_proto_sensor_proto_msgTypes[13].OneofWrappers = []any{
+		(*StreamReadingsRequest_SensorId)(nil),
+		(*StreamReadingsRequest_FieldId)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_sensor_proto_rawDesc), len(file_proto_sensor_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_sensor_proto_goTypes,
+		DependencyIndexes: file_proto_sensor_proto_depIdxs,
+		EnumInfos:         file_proto_sensor_proto_enumTypes,
+		MessageInfos:      file_proto_sensor_proto_msgTypes,
+	}.Build()
+	File_proto_sensor_proto = out.File
+	file_proto_sensor_proto_goTypes = nil
+	file_proto_sensor_proto_depIdxs = nil
+}
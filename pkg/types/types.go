@@ -35,6 +35,8 @@ type Sensor struct {
 	SensorType SensorType `json:"sensor_type"`
 	FieldID    *uuid.UUID `json:"field_id,omitempty"`
 	FieldName  string     `json:"field_name,omitempty"`
+	// DecommissionedAt is set once a sensor is retired; its readings stay queryable.
+	DecommissionedAt *time.Time `json:"decommissioned_at,omitempty"`
 }
 
 func ToSensorType(sensorType string) (SensorType, error) {
@@ -72,9 +74,217 @@ type Pipeline struct {
 }
 
 type Aggregate struct {
-	Avg       float64   `json:"avg"`
-	Min       float64   `json:"min"`
-	Max       float64   `json:"max"`
-	Count     int       `json:"count"`
+	Avg         float64            `json:"avg"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Count       int                `json:"count"`
+	Median      *float64           `json:"median,omitempty"`
+	StdDev      *float64           `json:"stddev,omitempty"`
+	Variance    *float64           `json:"variance,omitempty"`
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+	Timestamp   time.Time          `json:"timestamp"`
+}
+
+type SeriesPoint struct {
+	Start time.Time `json:"start"`
+	Avg   float64   `json:"avg"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Count int       `json:"count"`
+	First float64   `json:"first"`
+	Last  float64   `json:"last"`
+}
+
+// Reading is a single sensor reading as published on the sensor topics.
+type Reading struct {
+	SensorID   uuid.UUID  `json:"sensor_id"`
+	SensorType SensorType `json:"sensor_type"`
+	Timestamp  time.Time  `json:"timestamp"`
+	Value      float64    `json:"value"`
+}
+
+func (r Reading) Entry() Entry {
+	return Entry{Timestamp: r.Timestamp, Value: r.Value}
+}
+
+// Duration marshals as a Go duration string, like "5m".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type AlertComparator string
+
+const (
+	AlertComparatorGT  AlertComparator = "gt"
+	AlertComparatorGTE AlertComparator = "gte"
+	AlertComparatorLT  AlertComparator = "lt"
+	AlertComparatorLTE AlertComparator = "lte"
+)
+
+func (c AlertComparator) Valid() bool {
+	switch c {
+	case AlertComparatorGT, AlertComparatorGTE, AlertComparatorLT, AlertComparatorLTE:
+		return true
+	default:
+		return false
+	}
+}
+
+type AlertSeverity string
+
+const (
+	AlertSeverityInfo     AlertSeverity = "info"
+	AlertSeverityWarning  AlertSeverity = "warning"
+	AlertSeverityCritical AlertSeverity = "critical"
+)
+
+func (s AlertSeverity) Valid() bool {
+	switch s {
+	case AlertSeverityInfo, AlertSeverityWarning, AlertSeverityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+type AlertState string
+
+const (
+	AlertStateFiring   AlertState = "firing"
+	AlertStateResolved AlertState = "resolved"
+)
+
+// AlertRule fires when readings of one sensor, or of every sensor of SensorType in a
+// field, breach Threshold for at least For.
+type AlertRule struct {
+	RuleID  uuid.UUID `json:"rule_id"`
+	UserID  uuid.UUID `json:"user_id"`
+	FieldID uuid.UUID `json:"field_id"`
+	// SensorID scopes the rule to a single sensor, otherwise it covers the field.
+	SensorID *uuid.UUID `json:"sensor_id,omitempty"`
+	// SensorType is the metric the rule watches.
+	SensorType SensorType      `json:"sensor_type"`
+	Comparator AlertComparator `json:"comparator"`
+	Threshold  float64         `json:"threshold"`
+	// Hysteresis is how far back past Threshold readings must go to resolve a firing alert.
+	Hysteresis float64       `json:"hysteresis"`
+	For        Duration      `json:"for"`
+	Severity   AlertSeverity `json:"severity"`
+	Enabled    bool          `json:"enabled"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// Breached reports whether value violates the rule.
+func (r AlertRule) Breached(value float64) bool {
+	switch r.Comparator {
+	case AlertComparatorGT:
+		return value > r.Threshold
+	case AlertComparatorGTE:
+		return value >= r.Threshold
+	case AlertComparatorLT:
+		return value < r.Threshold
+	case AlertComparatorLTE:
+		return value <= r.Threshold
+	default:
+		return false
+	}
+}
+
+// Cleared reports whether value is back inside the allowed range by more than Hysteresis.
+func (r AlertRule) Cleared(value float64) bool {
+	switch r.Comparator {
+	case AlertComparatorGT:
+		return value <= r.Threshold-r.Hysteresis
+	case AlertComparatorGTE:
+		return value < r.Threshold-r.Hysteresis
+	case AlertComparatorLT:
+		return value >= r.Threshold+r.Hysteresis
+	case AlertComparatorLTE:
+		return value > r.Threshold+r.Hysteresis
+	default:
+		return true
+	}
+}
+
+// AlertEvent records a rule starting or stopping to fire for a sensor.
+type AlertEvent struct {
+	EventID    uuid.UUID       `json:"event_id"`
+	RuleID     uuid.UUID       `json:"rule_id"`
+	SensorID   uuid.UUID       `json:"sensor_id"`
+	FieldID    uuid.UUID       `json:"field_id"`
+	State      AlertState      `json:"state"`
+	Severity   AlertSeverity   `json:"severity"`
+	Comparator AlertComparator `json:"comparator"`
+	Threshold  float64         `json:"threshold"`
+	Value      float64         `json:"value"`
+	Timestamp  time.Time       `json:"timestamp"`
+}
+
+type NotificationChannelType string
+
+const (
+	NotificationChannelWebhook NotificationChannelType = "webhook"
+	NotificationChannelSlack   NotificationChannelType = "slack"
+	NotificationChannelMQTT    NotificationChannelType = "mqtt"
+)
+
+func (t NotificationChannelType) Valid() bool {
+	switch t {
+	case NotificationChannelWebhook, NotificationChannelSlack, NotificationChannelMQTT:
+		return true
+	default:
+		return false
+	}
+}
+
+// NotificationChannel delivers the alert events of a user's rules.
+type NotificationChannel struct {
+	ChannelID uuid.UUID               `json:"channel_id"`
+	UserID    uuid.UUID               `json:"user_id"`
+	Name      string                  `json:"name"`
+	Type      NotificationChannelType `json:"type"`
+	// URL is the endpoint of webhook and Slack channels.
+	URL string `json:"url,omitempty"`
+	// Secret signs webhook deliveries. It is only returned when the channel is created.
+	Secret string `json:"secret,omitempty"`
+	// FieldID restricts the channel to the alerts of one field.
+	FieldID *uuid.UUID `json:"field_id,omitempty"`
+	// MaxAttempts bounds deliveries of one event, Backoff is the delay before the first
+	// retry and doubles with every further one.
+	MaxAttempts int       `json:"max_attempts"`
+	Backoff     Duration  `json:"backoff"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AlertNotification is the payload delivered to notification channels.
+type AlertNotification struct {
+	Event AlertEvent `json:"event"`
+	Rule  AlertRule  `json:"rule"`
+	// Test marks notifications sent from the test endpoint.
+	Test bool `json:"test,omitempty"`
+}
+
+// DeadLetter records an alert notification that exhausted its delivery attempts.
+type DeadLetter struct {
+	LetterID  uuid.UUID `json:"letter_id"`
+	ChannelID uuid.UUID `json:"channel_id"`
+	RuleID    uuid.UUID `json:"rule_id"`
+	EventID   uuid.UUID `json:"event_id"`
+	Payload   string    `json:"payload"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
 	Timestamp time.Time `json:"timestamp"`
 }
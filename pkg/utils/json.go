@@ -26,6 +26,18 @@ func ReplyBadRequest(w http.ResponseWriter, err string) error {
 	})
 }
 
+func ReplyUnauthorized(w http.ResponseWriter, err string) error {
+	return ReplyJSON(w, http.StatusUnauthorized, Body{
+		"error": err,
+	})
+}
+
+func ReplyForbidden(w http.ResponseWriter, err string) error {
+	return ReplyJSON(w, http.StatusForbidden, Body{
+		"error": err,
+	})
+}
+
 func ReplyInternalServerError(w http.ResponseWriter, err string) error {
 	return ReplyJSON(w, http.StatusInternalServerError, Body{
 		"error": err,